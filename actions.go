@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/mmngadi/touchpad-tool/internal/drivers"
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+)
+
+// Action is what a bound gesture does on the desktop. Every field that is
+// set runs, in the order they are declared:
+//
+//	Button  clicks "left", "right" or "middle"
+//	Keys    presses a chord such as "super+ctrl+left"
+//	Scroll  sends that many wheel notches (positive is up)
+//	Command runs through the system shell without waiting for it
+//	Profile switches the active profile
+type Action struct {
+	Button  string `json:"button,omitempty"`
+	Keys    string `json:"keys,omitempty"`
+	Scroll  int32  `json:"scroll,omitempty"`
+	Command string `json:"command,omitempty"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// IsZero reports whether the action does nothing, which is how a config
+// file unbinds a gesture that is bound by default.
+func (a Action) IsZero() bool {
+	return a == Action{}
+}
+
+func (a Action) Run() {
+	if a.Button != "" {
+		driver.Button(a.Button, true)
+		driver.Button(a.Button, false)
+	}
+	if a.Keys != "" {
+		pressChord(a.Keys)
+	}
+	if a.Scroll != 0 {
+		driver.Scroll(a.Scroll * drivers.WheelNotch)
+	}
+	if a.Command != "" {
+		runShell(a.Command)
+	}
+	if a.Profile != "" {
+		cfg.Profile = a.Profile
+		fmt.Printf("[*] Switched to profile %q\n", a.Profile)
+	}
+}
+
+// binding returns the action bound to a gesture name, if any.
+func binding(gesture string) (Action, bool) {
+	a, ok := cfg.Bindings[gesture]
+	return a, ok && !a.IsZero()
+}
+
+// anyBinding reports whether any gesture starting with prefix is bound, so
+// recognizers for unbound gestures can stay out of the way entirely.
+func anyBinding(prefix string) bool {
+	for name, a := range cfg.Bindings {
+		if strings.HasPrefix(name, prefix) && !a.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// gestureNames lists every gesture the engine can recognize:
+// tap-N and hold-N for 1-4 fingers, swipe-N-<dir> for 3 or 4 fingers,
+// rotate-cw / rotate-ccw, and the phone's volume-up / volume-down keys.
+func gestureNames() map[string]bool {
+	names := map[string]bool{
+		"rotate-cw":         true,
+		"rotate-ccw":        true,
+		proto.KeyVolumeUp:   true,
+		proto.KeyVolumeDown: true,
+	}
+	for n := 1; n <= 4; n++ {
+		names[fmt.Sprintf("tap-%d", n)] = true
+		names[fmt.Sprintf("hold-%d", n)] = true
+	}
+	for _, n := range []int{3, 4} {
+		for _, dir := range []string{"up", "down", "left", "right"} {
+			names[fmt.Sprintf("swipe-%d-%s", n, dir)] = true
+		}
+	}
+	return names
+}
+
+// knownButton reports whether the drivers can press button. They press
+// the left button for any other name.
+func knownButton(button string) bool {
+	switch button {
+	case "left", "right", "middle":
+		return true
+	}
+	return false
+}
+
+// checkBindings warns about bindings that can never fire or are malformed
+// and returns c's bindings without the buttons the drivers do not know.
+func checkBindings(c Config) map[string]Action {
+	for _, w := range bindingWarnings(c) {
+		fmt.Println("[!] " + w)
+	}
+	bindings := maps.Clone(c.Bindings)
+	for name, a := range bindings {
+		if a.Button != "" && !knownButton(a.Button) {
+			a.Button = ""
+			bindings[name] = a
+		}
+	}
+	return bindings
+}
+
+// bindingWarnings returns what checkBindings reports, sorted by gesture.
+func bindingWarnings(c Config) []string {
+	names := gestureNames()
+	var warnings []string
+	for _, name := range slices.Sorted(maps.Keys(c.Bindings)) {
+		a := c.Bindings[name]
+		if !names[name] {
+			warnings = append(warnings, fmt.Sprintf("Unknown gesture %q in bindings", name))
+		}
+		if a.Button != "" && !knownButton(a.Button) {
+			warnings = append(warnings, fmt.Sprintf("Binding %q: unknown button %q", name, a.Button))
+		}
+		if a.Keys != "" {
+			if _, err := drivers.ParseChord(a.Keys); err != nil {
+				warnings = append(warnings, fmt.Sprintf("Binding %q: %v", name, err))
+			}
+		}
+		if a.Profile != "" {
+			if _, ok := c.Profiles[a.Profile]; !ok {
+				warnings = append(warnings, fmt.Sprintf("Binding %q switches to unknown profile %q", name, a.Profile))
+			}
+		}
+	}
+	return warnings
+}
+
+func pressChord(keys string) {
+	chord, err := drivers.ParseChord(keys)
+	if err != nil {
+		fmt.Printf("[-] Bad key chord %q: %v\n", keys, err)
+		return
+	}
+	chord.Press(driver)
+}
+
+func runShell(command string) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Printf("[-] Gesture command failed: %v\n", err)
+		return
+	}
+	go cmd.Wait()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/mmngadi/touchpad-tool/internal/drivers"
+)
+
+func TestBindingWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		bindings map[string]Action
+		want     []string
+	}{
+		{
+			name: "valid",
+			bindings: map[string]Action{
+				"tap-2":         {Button: "right"},
+				"hold-3":        {Keys: "super+tab"},
+				"swipe-4-left":  {Keys: "Control+Alt+Left"},
+				"rotate-cw":     {Keys: "ctrl+]"},
+				"volume-up":     {Scroll: 1},
+				"swipe-3-down":  {Profile: "default"},
+				"tap-4":         {Command: "true"},
+				"swipe-3-up":    {},
+				"hold-1":        {Keys: " WIN + d "},
+				"swipe-4-right": {Keys: "cmd+escape"},
+				"rotate-ccw":    {Keys: "ctrl+["},
+				"volume-down":   {Scroll: -1},
+				"swipe-4-up":    {Keys: "super+pgup"},
+				"swipe-4-down":  {Keys: "super+pgdn"},
+				"swipe-3-left":  {Keys: "alt+shift+tab"},
+				"swipe-3-right": {Keys: "alt+tab"},
+				"hold-2":        {Button: "middle"},
+				"hold-4":        {Keys: "super"},
+				"tap-3":         {Button: "middle"},
+				"tap-1":         {Button: "left"},
+			},
+		},
+		{
+			name: "unknown gestures",
+			bindings: map[string]Action{
+				"tap-5":        {Button: "left"},
+				"swipe-2-up":   {Keys: "super"},
+				"Tap-2":        {Button: "right"},
+				"pinch-in":     {Keys: "ctrl+minus"},
+				"swipe-3-diag": {Keys: "super"},
+			},
+			want: []string{
+				`Unknown gesture "Tap-2" in bindings`,
+				`Unknown gesture "pinch-in" in bindings`,
+				`Unknown gesture "swipe-2-up" in bindings`,
+				`Unknown gesture "swipe-3-diag" in bindings`,
+				`Unknown gesture "tap-5" in bindings`,
+			},
+		},
+		{
+			name: "bad keys",
+			bindings: map[string]Action{
+				"tap-3":        {Keys: "ctrl+shfit+t"},
+				"swipe-3-left": {Keys: "super+"},
+				"hold-2":       {Keys: "hyper+space"},
+			},
+			want: []string{
+				`Binding "hold-2": unknown key "hyper"`,
+				`Binding "swipe-3-left": unknown key ""`,
+				`Binding "tap-3": unknown key "shfit"`,
+			},
+		},
+		{
+			name: "unknown buttons",
+			bindings: map[string]Action{
+				"tap-2":       {Button: "rigth"},
+				"volume-down": {Button: "Left"},
+				"tap-3":       {Button: "middle"},
+			},
+			want: []string{
+				`Binding "tap-2": unknown button "rigth"`,
+				`Binding "volume-down": unknown button "Left"`,
+			},
+		},
+		{
+			name: "unknown profile",
+			bindings: map[string]Action{
+				"swipe-4-up":   {Profile: "Default"},
+				"swipe-4-down": {Profile: "presenting"},
+			},
+			want: []string{
+				`Binding "swipe-4-down" switches to unknown profile "presenting"`,
+				`Binding "swipe-4-up" switches to unknown profile "Default"`,
+			},
+		},
+		{
+			name: "everything wrong at once",
+			bindings: map[string]Action{
+				"tap-9": {Keys: "ctrl+nope", Profile: "missing"},
+			},
+			want: []string{
+				`Unknown gesture "tap-9" in bindings`,
+				`Binding "tap-9": unknown key "nope"`,
+				`Binding "tap-9" switches to unknown profile "missing"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaultConfig()
+			c.Bindings = tt.bindings
+			if got := bindingWarnings(c); !slices.Equal(got, tt.want) {
+				t.Errorf("bindingWarnings =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBindingsDropsUnknownButtons(t *testing.T) {
+	c := defaultConfig()
+	c.Bindings = map[string]Action{
+		"tap-2": {Button: "rigth"},
+		"tap-3": {Button: "wheel", Keys: "ctrl+w"},
+		"tap-1": {Button: "left"},
+	}
+	got := checkBindings(c)
+	want := map[string]Action{
+		"tap-2": {},
+		"tap-3": {Keys: "ctrl+w"},
+		"tap-1": {Button: "left"},
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("checkBindings = %v, want %v", got, want)
+	}
+	if c.Bindings["tap-2"].Button != "rigth" {
+		t.Error("checkBindings changed the bindings it was given")
+	}
+}
+
+func TestDefaultBindingsAreValid(t *testing.T) {
+	c := defaultConfig()
+	if got := bindingWarnings(c); len(got) != 0 {
+		t.Errorf("default config warns: %q", got)
+	}
+}
+
+func TestActionScrollsWholeNotches(t *testing.T) {
+	_, f := newTestEngine(t)
+	cfg.ScrollSpeed = 90 // gesture scrolling only
+	Action{Scroll: 2}.Run()
+	Action{Scroll: -1}.Run()
+	want := []string{
+		fmt.Sprintf("scroll %d", 2*drivers.WheelNotch),
+		fmt.Sprintf("scroll %d", -drivers.WheelNotch),
+	}
+	if got := f.Events(); !slices.Equal(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
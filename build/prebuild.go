@@ -53,7 +53,7 @@ func main() {
 
 	// 2. Patch APK
 	fmt.Println("[2/4] Applying Patch Logic...")
-	cmdPatch := exec.Command("go", "run", "./internal/patch/main.go")
+	cmdPatch := exec.Command("go", "run", "./internal/patch")
 	cmdPatch.Dir = root
 	if err := runCmd(cmdPatch); err != nil {
 		os.Exit(1)
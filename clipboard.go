@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mmngadi/touchpad-tool/internal/clipboard"
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+)
+
+// ClipboardConfig turns on sharing copied text between the PC and the
+// phone. It is off unless enabled, since whatever is copied on one side
+// ends up on the other.
+type ClipboardConfig struct {
+	Sync bool `json:"sync"`
+
+	// MaxBytes is the largest text shared; anything bigger stays on the
+	// side it was copied on.
+	MaxBytes int `json:"max_bytes"`
+}
+
+// clipboardPoll is how often the PC clipboard is checked for changes.
+const clipboardPoll = time.Second
+
+var (
+	clipMu   sync.Mutex
+	clipLast string // text last copied on either side
+)
+
+// clipboardLimit is what the app is told to share, 0 when sync is off.
+func clipboardLimit(c ClipboardConfig) int {
+	if !c.Sync {
+		return 0
+	}
+	return c.MaxBytes
+}
+
+func clipboardShareable(c ClipboardConfig, text string) bool {
+	return text != "" && len(text) <= c.MaxBytes && utf8.ValidString(text)
+}
+
+// startClipboardSync watches the PC clipboard and sends text copied after
+// the session started to the app. It turns sync off in cfg if the clipboard
+// cannot be read, so it must run before the engine starts.
+func startClipboardSync() {
+	if !cfg.Clipboard.Sync {
+		return
+	}
+	text, err := clipboard.Read()
+	if err != nil && !errors.Is(err, clipboard.ErrNoText) {
+		fmt.Printf("[!] Clipboard sync unavailable: %v\n", err)
+		cfg.Clipboard.Sync = false
+		return
+	}
+	clipLast = text
+
+	c := cfg.Clipboard
+	go func() {
+		for !isExiting.Load() {
+			time.Sleep(clipboardPoll)
+			text, err := clipboard.Read()
+			if err != nil {
+				continue
+			}
+			clipMu.Lock()
+			changed := text != clipLast
+			clipLast = text
+			clipMu.Unlock()
+			if changed && clipboardShareable(c, text) {
+				link.Send(proto.Message{Type: proto.Clipboard, Text: text})
+			}
+		}
+	}()
+}
+
+// receiveClipboard puts text copied on the phone on the PC clipboard.
+func receiveClipboard(c ClipboardConfig, text string) {
+	if !c.Sync || !clipboardShareable(c, text) {
+		return
+	}
+	clipMu.Lock()
+	defer clipMu.Unlock()
+	if text == clipLast {
+		return
+	}
+	if err := clipboard.Write(text); err != nil {
+		fmt.Printf("[!] Could not set the clipboard: %v\n", err)
+		return
+	}
+	clipLast = text
+}
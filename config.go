@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mmngadi/touchpad-tool/internal/layout"
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+)
+
+const configName = "touchpad-tool.json"
+
+// Config holds the user-tunable parts of the gesture engine. Anything left
+// out of the JSON file keeps its value from defaultConfig.
+type Config struct {
+	// Sensitivity scales finger travel in digitizer units to pointer pixels.
+	Sensitivity float64 `json:"sensitivity"`
+
+	// ScrollSpeed is the wheel delta sent per notch of scroll travel.
+	ScrollSpeed int32 `json:"scroll_speed"`
+
+	// Timeouts, in milliseconds, for a finger to count as a tap, for the
+	// second tap of a double-tap drag, and for a hold to fire.
+	TapTimeoutMs       int `json:"tap_timeout_ms"`
+	DoubleTapTimeoutMs int `json:"double_tap_timeout_ms"`
+	HoldTimeoutMs      int `json:"hold_timeout_ms"`
+
+	// Bindings maps gesture names (see gestureNames) to actions. Gestures
+	// with no binding, or bound to an empty action, are not recognized at
+	// all, so they cannot add latency to or steal input from other ones.
+	Bindings map[string]Action `json:"bindings"`
+
+	// SwipeDistance is how far (in pad space, 0..1) the fingers of a
+	// three- or four-finger swipe must travel before it fires.
+	SwipeDistance float64 `json:"swipe_distance"`
+
+	// PinchStep is the relative change in finger distance (as a log
+	// ratio) that produces one Ctrl+wheel zoom notch.
+	PinchStep float64 `json:"pinch_step"`
+
+	// RotateStep is the rotation in degrees between two rotate actions.
+	RotateStep float64 `json:"rotate_step"`
+
+	// Drag configures drag lock and edge motion while dragging.
+	Drag DragConfig `json:"drag"`
+
+	// Palm configures palm, thumb and edge contact rejection.
+	Palm PalmConfig `json:"palm"`
+
+	// Profile names the entry of Profiles in use.
+	Profile  string             `json:"profile"`
+	Profiles map[string]Profile `json:"profiles"`
+
+	// Haptics sets how strongly the phone vibrates on clicks and drags.
+	Haptics HapticConfig `json:"haptics"`
+
+	// Back is how the app can be closed from the phone, which ends the
+	// session: "double" press of Back, "long" press, "volume" for both
+	// volume keys together, or "disabled".
+	Back string `json:"back"`
+
+	// Power configures low power mode and the battery warning.
+	Power PowerConfig `json:"power"`
+
+	// Clipboard configures clipboard sharing with the phone.
+	Clipboard ClipboardConfig `json:"clipboard"`
+
+	// Device is the identity of the virtual input device on Linux.
+	Device DeviceConfig `json:"device"`
+
+	// Input selects where touches come from: "getevent" reads the touch
+	// device over adb, "app" uses touches captured by the app itself.
+	Input string `json:"input"`
+
+	// Display is how the app draws touch and mode feedback: "normal",
+	// "dim" to save power on OLED screens, or "off".
+	Display string `json:"display"`
+
+	// Zones are clickpad-style button areas. A finger resting in one holds
+	// its button while other fingers keep moving the pointer.
+	Zones []layout.Zone `json:"button_zones"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Sensitivity:        3.2,
+		ScrollSpeed:        120,
+		TapTimeoutMs:       200,
+		DoubleTapTimeoutMs: 250,
+		HoldTimeoutMs:      600,
+		Bindings: map[string]Action{
+			"tap-1":         {Button: "left"},
+			"tap-2":         {Button: "right"},
+			"tap-3":         {Button: "middle"},
+			"hold-1":        {Button: "right"},
+			"swipe-3-up":    {Keys: "super+tab"},
+			"swipe-3-down":  {Keys: "super+d"},
+			"swipe-3-left":  {Keys: "alt+shift+tab"},
+			"swipe-3-right": {Keys: "alt+tab"},
+			"swipe-4-up":    {Keys: "super+up"},
+			"swipe-4-down":  {Keys: "super+down"},
+			"swipe-4-left":  {Keys: "super+ctrl+left"},
+			"swipe-4-right": {Keys: "super+ctrl+right"},
+		},
+		SwipeDistance: 0.15,
+		Input:         inputGetevent,
+		Display:       proto.DisplayNormal,
+		Back:          proto.ExitDouble,
+		Profile:       "default",
+		Profiles: map[string]Profile{
+			"default": {
+				ScrollMode: scrollTwoFinger,
+				EdgeScroll: Edges{Right: 0.1, Bottom: 0.12},
+			},
+			"one-hand": {
+				ScrollMode: scrollBoth,
+				EdgeScroll: Edges{Right: 0.12, Bottom: 0.15},
+			},
+		},
+		PinchStep:  0.12,
+		RotateStep: 30,
+		Drag: DragConfig{
+			LockTimeoutMs:   1000,
+			EdgeMotion:      0.06,
+			EdgeMotionSpeed: 8,
+		},
+		Haptics: HapticConfig{
+			Click:     0.4,
+			LongPress: 0.7,
+			DragStart: 0.5,
+			DragEnd:   0.3,
+		},
+		Clipboard: ClipboardConfig{
+			MaxBytes: 64 << 10,
+		},
+		Device: DeviceConfig{
+			Name:    "Touchpad Tool",
+			Bus:     "virtual",
+			Vendor:  0x7470,
+			Product: 0x0001,
+		},
+		Power: PowerConfig{
+			BatteryWarning: 15,
+		},
+		Palm: PalmConfig{
+			MaxSize:     0.6,
+			Edges:       Edges{Left: 0.04, Right: 0.04, Top: 0.03, Bottom: 0.05},
+			EdgeGraceMs: 300,
+			ThumbRestMs: 500,
+		},
+	}
+}
+
+// loadConfig reads touchpad-tool.json from the working directory, falling
+// back to the directory of the executable. A missing file is not an error.
+func loadConfig() Config {
+	cfg := defaultConfig()
+
+	paths := []string{configName}
+	if exe, err := os.Executable(); err == nil {
+		paths = append(paths, filepath.Join(filepath.Dir(exe), configName))
+	}
+
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			fmt.Printf("[!] Ignoring %s: %v\n", p, err)
+			return defaultConfig()
+		}
+		fmt.Printf("[*] Loaded config from %s\n", p)
+		break
+	}
+
+	if _, ok := cfg.Profiles[cfg.Profile]; !ok {
+		fmt.Printf("[!] Unknown profile %q, using defaults\n", cfg.Profile)
+	}
+	cfg.Bindings = checkBindings(cfg)
+	cfg.Zones = checkZones(cfg.Zones)
+	if cfg.Input != inputGetevent && cfg.Input != inputApp {
+		fmt.Printf("[!] Unknown input %q, using %s\n", cfg.Input, inputGetevent)
+		cfg.Input = inputGetevent
+	}
+	switch cfg.Back {
+	case proto.ExitDisabled, proto.ExitDouble, proto.ExitLong, proto.ExitVolume:
+	default:
+		fmt.Printf("[!] Unknown back %q, using %s\n", cfg.Back, proto.ExitDouble)
+		cfg.Back = proto.ExitDouble
+	}
+	if _, ok := busTypes[cfg.Device.Bus]; !ok {
+		fmt.Printf("[!] Unknown device bus %q, using virtual\n", cfg.Device.Bus)
+		cfg.Device.Bus = "virtual"
+	}
+	if cfg.Device.Name == "" {
+		cfg.Device.Name = defaultConfig().Device.Name
+	}
+	switch cfg.Display {
+	case proto.DisplayNormal, proto.DisplayDim, proto.DisplayOff:
+	default:
+		fmt.Printf("[!] Unknown display %q, using %s\n", cfg.Display, proto.DisplayNormal)
+		cfg.Display = proto.DisplayNormal
+	}
+
+	// Step sizes divide gesture travel, so they must stay positive.
+	def := defaultConfig()
+	if cfg.PinchStep <= 0 {
+		cfg.PinchStep = def.PinchStep
+	}
+	if cfg.RotateStep <= 0 {
+		cfg.RotateStep = def.RotateStep
+	}
+	return cfg
+}
+
+// ms converts a millisecond setting into a duration.
+func ms(n int) time.Duration {
+	return time.Duration(n) * time.Millisecond
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// The control API is a small HTTP server on localhost that lets a running
+// session be inspected and retuned without restarting it. Requests must
+// carry the bearer token from the session file, which is only readable by
+// the user running the tool.
+
+// controlSession is written to controlSessionPath while the tool runs so
+// `touchpad-tool ctl` can find and authenticate to it.
+type controlSession struct {
+	Addr  string `json:"addr"`
+	Token string `json:"token"`
+}
+
+// controlState is what GET /state returns.
+type controlState struct {
+	Paused             bool    `json:"paused"`
+	Foreground         bool    `json:"foreground"`
+	Profile            string  `json:"profile"`
+	Sensitivity        float64 `json:"sensitivity"`
+	ScrollSpeed        int32   `json:"scroll_speed"`
+	TapTimeoutMs       int     `json:"tap_timeout_ms"`
+	DoubleTapTimeoutMs int     `json:"double_tap_timeout_ms"`
+	HoldTimeoutMs      int     `json:"hold_timeout_ms"`
+	Battery            int     `json:"battery"` // percent, -1 if unknown
+	Charging           bool    `json:"charging"`
+}
+
+// tunable lists the config keys POST /set accepts.
+var tunable = map[string]bool{
+	"profile":               true,
+	"sensitivity":           true,
+	"scroll_speed":          true,
+	"tap_timeout_ms":        true,
+	"double_tap_timeout_ms": true,
+	"hold_timeout_ms":       true,
+}
+
+// newToken returns a random secret for authenticating a session.
+func newToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func controlSessionPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "touchpad-tool", "control.json"), nil
+}
+
+// startControlServer serves the control API for engine and records the
+// session file. It returns the path to remove on shutdown, or "" if the API
+// could not be started; the tool keeps running without it.
+func startControlServer(engine *gestureEngine, s linkSettings) string {
+	path, err := controlSessionPath()
+	if err != nil {
+		fmt.Printf("[!] Control API disabled: %v\n", err)
+		return ""
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Printf("[!] Control API disabled: %v\n", err)
+		return ""
+	}
+
+	token, err := newToken()
+	if err != nil {
+		fmt.Printf("[!] Control API disabled: %v\n", err)
+		ln.Close()
+		return ""
+	}
+	session := controlSession{Addr: ln.Addr().String(), Token: token}
+	data, _ := json.Marshal(session)
+	_ = os.MkdirAll(filepath.Dir(path), 0700)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		fmt.Printf("[!] Control API disabled: %v\n", err)
+		ln.Close()
+		return ""
+	}
+
+	// reply runs fn on the engine goroutine and answers with the new state.
+	reply := func(w http.ResponseWriter, fn func() error) {
+		var st controlState
+		var err error
+		if !engine.Do(func() { err = fn(); st = engine.state() }) {
+			http.Error(w, "session is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, st)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /state", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, func() error { return nil })
+	})
+	mux.HandleFunc("POST /set", func(w http.ResponseWriter, r *http.Request) {
+		var patch map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		reply(w, func() error { return applyTuning(patch) })
+	})
+	// Pausing goes through the same path as the phone's pause button, so
+	// the phone is handed back and set up again in the same way.
+	mux.HandleFunc("POST /pause", func(w http.ResponseWriter, r *http.Request) {
+		pauseSession(engine, "the control API")
+		reply(w, func() error { return nil })
+	})
+	mux.HandleFunc("POST /resume", func(w http.ResponseWriter, r *http.Request) {
+		resumeSession(engine, s, "the control API")
+		reply(w, func() error { return nil })
+	})
+
+	auth := func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+session.Token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}
+	go http.Serve(ln, http.HandlerFunc(auth))
+
+	fmt.Printf("[*] Control API on %s\n", session.Addr)
+	return path
+}
+
+// state snapshots the tunables; it must run on the engine goroutine.
+func (e *gestureEngine) state() controlState {
+	return controlState{
+		Paused:             e.paused,
+		Foreground:         appInForeground.Load(),
+		Profile:            cfg.Profile,
+		Sensitivity:        cfg.Sensitivity,
+		ScrollSpeed:        cfg.ScrollSpeed,
+		TapTimeoutMs:       cfg.TapTimeoutMs,
+		DoubleTapTimeoutMs: cfg.DoubleTapTimeoutMs,
+		HoldTimeoutMs:      cfg.HoldTimeoutMs,
+		Battery:            int(batteryLevel.Load()),
+		Charging:           batteryCharging.Load(),
+	}
+}
+
+// applyTuning merges a JSON patch of tunable keys into cfg. Nothing is
+// changed unless the whole patch is valid. It must run on the engine
+// goroutine.
+func applyTuning(patch map[string]json.RawMessage) error {
+	for key := range patch {
+		if !tunable[key] {
+			return fmt.Errorf("%q cannot be changed at runtime", key)
+		}
+	}
+	data, _ := json.Marshal(patch)
+	next := cfg
+	if err := json.Unmarshal(data, &next); err != nil {
+		return err
+	}
+	if _, ok := next.Profiles[next.Profile]; !ok {
+		return fmt.Errorf("unknown profile %q", next.Profile)
+	}
+	if next.Sensitivity <= 0 || next.ScrollSpeed <= 0 || next.TapTimeoutMs <= 0 ||
+		next.DoubleTapTimeoutMs <= 0 || next.HoldTimeoutMs <= 0 {
+		return fmt.Errorf("values must be positive")
+	}
+	cfg = next
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const ctlUsage = `usage: touchpad-tool ctl <command>
+
+commands:
+  status                 show the running session's settings
+  set <key> <value>...   change settings, e.g. set sensitivity 2.5
+  profile <name>         switch profile
+  pause                  stop sending input to the PC
+  resume                 start sending input again
+
+keys: sensitivity, scroll_speed, tap_timeout_ms, double_tap_timeout_ms,
+      hold_timeout_ms, profile
+`
+
+// runCtl implements the `ctl` subcommand, a client for the control API of a
+// running session. It returns the process exit code.
+func runCtl(args []string) int {
+	if len(args) == 0 {
+		fmt.Print(ctlUsage)
+		return 2
+	}
+
+	var method, route string
+	var body []byte
+	switch args[0] {
+	case "status":
+		method, route = "GET", "/state"
+	case "pause", "resume":
+		method, route = "POST", "/"+args[0]
+	case "profile":
+		if len(args) != 2 {
+			fmt.Print(ctlUsage)
+			return 2
+		}
+		method, route = "POST", "/set"
+		body, _ = json.Marshal(map[string]string{"profile": args[1]})
+	case "set":
+		if len(args) < 3 || len(args)%2 != 1 {
+			fmt.Print(ctlUsage)
+			return 2
+		}
+		patch := map[string]any{}
+		for i := 1; i < len(args); i += 2 {
+			patch[args[i]] = ctlValue(args[i], args[i+1])
+		}
+		method, route = "POST", "/set"
+		body, _ = json.Marshal(patch)
+	default:
+		fmt.Print(ctlUsage)
+		return 2
+	}
+
+	path, err := controlSessionPath()
+	if err != nil {
+		fmt.Printf("[-] %v\n", err)
+		return 1
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Println("[-] No running session found (is touchpad-tool running as this user?)")
+		return 1
+	}
+	var session controlSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		fmt.Printf("[-] Bad session file %s: %v\n", path, err)
+		return 1
+	}
+
+	req, _ := http.NewRequest(method, "http://"+session.Addr+route, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Printf("[-] Session not responding: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("[-] %s\n", strings.TrimSpace(string(out)))
+		return 1
+	}
+
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, out, "", "  ") == nil {
+		out = pretty.Bytes()
+	}
+	fmt.Println(strings.TrimSpace(string(out)))
+	return 0
+}
+
+// ctlValue sends numbers as JSON numbers and everything else as strings.
+// Profile names are always strings, even ones that look like numbers.
+func ctlValue(key, s string) any {
+	if key == "profile" {
+		return s
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
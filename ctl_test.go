@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCtlValue(t *testing.T) {
+	tests := []struct {
+		key, value string
+		want       string // JSON encoding
+	}{
+		{"sensitivity", "2.5", `2.5`},
+		{"scroll_speed", "120", `120`},
+		{"hold_timeout_ms", "fast", `"fast"`},
+		{"profile", "one-hand", `"one-hand"`},
+		{"profile", "2024", `"2024"`},
+		{"profile", "1e3", `"1e3"`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(ctlValue(tt.key, tt.value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("ctlValue(%q, %q) = %s, want %s", tt.key, tt.value, data, tt.want)
+		}
+	}
+}
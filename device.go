@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/mmngadi/touchpad-tool/internal/drivers"
+)
+
+// DeviceConfig sets the identity of the virtual input device on Linux, so
+// udev rules, hwdb entries and libinput quirks can single it out. The
+// generated rules (see runUdev) match on the bus, vendor and product.
+type DeviceConfig struct {
+	// Name is followed by the phone's model in the device name.
+	Name    string `json:"name"`
+	Bus     string `json:"bus"` // "virtual", "usb" or "bluetooth"
+	Vendor  uint16 `json:"vendor"`
+	Product uint16 `json:"product"`
+}
+
+var busTypes = map[string]uint16{
+	"virtual":   drivers.BusVirtual,
+	"usb":       drivers.BusUSB,
+	"bluetooth": drivers.BusBluetooth,
+}
+
+// identity is the device identity for the given phone model, which may be
+// empty if it is unknown.
+func (d DeviceConfig) identity(model string) drivers.Identity {
+	name := d.Name
+	if model != "" {
+		name = fmt.Sprintf("%s (%s)", name, model)
+	}
+	return drivers.Identity{
+		Name:    name,
+		Bus:     busTypes[d.Bus],
+		Vendor:  d.Vendor,
+		Product: d.Product,
+		Version: 1,
+	}
+}
+
+// phoneModel asks the connected phone for its model name.
+func phoneModel() string {
+	out, err := exec.Command(adbPath, "shell", "getprop", "ro.product.model").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"time"
+)
+
+// DragConfig tunes double-tap-and-hold dragging.
+type DragConfig struct {
+	// Lock keeps the button held when a drag that moved is lifted, so the
+	// drag can continue with the next touch. A tap ends it, and so does
+	// LockTimeoutMs passing without a new touch.
+	Lock          bool `json:"lock"`
+	LockTimeoutMs int  `json:"lock_timeout_ms"`
+
+	// EdgeMotion is the width (pad space) of the border where a resting
+	// dragging finger keeps the pointer moving outwards at EdgeMotionSpeed
+	// pixels per tick. Zero disables it.
+	EdgeMotion      float64 `json:"edge_motion"`
+	EdgeMotionSpeed float64 `json:"edge_motion_speed"`
+}
+
+const edgeMotionTick = 16 * time.Millisecond
+
+// lockDrag keeps the left button held after the dragging finger lifts.
+func (e *gestureEngine) lockDrag() {
+	e.dragLocked = true
+	e.dragLockAt = time.Now().Add(time.Duration(cfg.Drag.LockTimeoutMs) * time.Millisecond)
+}
+
+// resumeDrag continues a locked drag with a new touch.
+func (e *gestureEngine) resumeDrag() {
+	e.dragLockAt = time.Time{}
+	e.dragLocked = false
+	e.dragResumed = true
+}
+
+func (e *gestureEngine) endDrag() {
+	e.stopEdgeMotion()
+	if e.isDragging {
+		driver.Button("left", false)
+		haptic(hapticDragEnd)
+	}
+	e.isDragging, e.dragResumed = false, false
+}
+
+// updateEdgeMotion starts, steers or stops edge motion for the dragging
+// finger depending on how close it is to the border of the pad.
+func (e *gestureEngine) updateEdgeMotion(c *touchContact) {
+	w := cfg.Drag.EdgeMotion
+	if w <= 0 {
+		return
+	}
+	x, y := e.pad.Normalize(c.x, c.y)
+	var vx, vy float64
+	switch {
+	case x < w:
+		vx = -1
+	case x > 1-w:
+		vx = 1
+	}
+	switch {
+	case y < w:
+		vy = -1
+	case y > 1-w:
+		vy = 1
+	}
+	if vx == 0 && vy == 0 {
+		e.stopEdgeMotion()
+		return
+	}
+
+	speed := cfg.Drag.EdgeMotionSpeed
+	e.edgeDX, e.edgeDY = int32(vx*speed), int32(vy*speed)
+	if e.edgeNext.IsZero() {
+		e.edgeNext = time.Now().Add(edgeMotionTick)
+	}
+}
+
+func (e *gestureEngine) stopEdgeMotion() {
+	e.edgeNext = time.Time{}
+}
+
+// Release lets go of everything the engine is holding. It runs when the
+// input stream ends and on shutdown so no button is left stuck down.
+func (e *gestureEngine) Release() {
+	e.stopLongPress()
+	e.dragLockAt = time.Time{}
+	e.dragLocked = false
+	e.endDrag()
+	e.releasePhoneKeys()
+	for _, c := range e.contacts.Active() {
+		if c.pressed != "" {
+			driver.Button(c.pressed, false)
+			c.pressed = ""
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mmngadi/touchpad-tool/internal/drivers"
+)
+
+// fakeDriver records everything the engine sends to the host.
+type fakeDriver struct {
+	mu      sync.Mutex
+	events  []string // buttons, scrolls and keys in the order sent
+	dx, dy  int64    // summed pointer motion
+	moves   int
+	scrolls int64
+}
+
+func (f *fakeDriver) record(format string, args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeDriver) Move(dx, dy int32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dx += int64(dx)
+	f.dy += int64(dy)
+	f.moves++
+}
+
+func (f *fakeDriver) Button(button string, down bool) {
+	state := "up"
+	if down {
+		state = "down"
+	}
+	f.record("%s %s", button, state)
+}
+
+func (f *fakeDriver) Scroll(delta int32) {
+	f.mu.Lock()
+	f.scrolls += int64(delta)
+	f.mu.Unlock()
+	f.record("scroll %d", delta)
+}
+
+func (f *fakeDriver) HScroll(delta int32) { f.record("hscroll %d", delta) }
+
+func (f *fakeDriver) Key(k drivers.Key, down bool) {
+	state := "up"
+	if down {
+		state = "down"
+	}
+	f.record("key %s %s", k, state)
+}
+
+func (f *fakeDriver) Type(text string) int {
+	f.record("type %q", text)
+	return 0
+}
+
+func (f *fakeDriver) Close() {}
+
+// Events returns a copy of the recorded events.
+func (f *fakeDriver) Events() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.events...)
+}
+
+// Motion returns the summed pointer motion.
+func (f *fakeDriver) Motion() (int64, int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dx, f.dy
+}
+
+// testPad is the digitizer every engine test runs against.
+var testPad = digitizer{maxX: 1080, maxY: 2400, maxMajor: 255}
+
+// newTestEngine installs a fake driver and the default config and returns
+// an engine with the app in front. Haptics are turned off so nothing
+// reaches for adb.
+func newTestEngine(t *testing.T) (*gestureEngine, *fakeDriver) {
+	t.Helper()
+	f := &fakeDriver{}
+	oldDriver, oldCfg, oldFront := driver, cfg, appInForeground.Load()
+	t.Cleanup(func() {
+		driver, cfg = oldDriver, oldCfg
+		appInForeground.Store(oldFront)
+	})
+	driver = f
+	cfg = defaultConfig()
+	cfg.Haptics = HapticConfig{}
+	appInForeground.Store(true)
+
+	e := newGestureEngine()
+	e.pad = testPad
+	return e, f
+}
+
+// eventLines turns `getevent -l` lines, with or without the device
+// prefix, into input events. Blank lines and # comments are skipped.
+func eventLines(t *testing.T, lines ...string) []inputEvent {
+	t.Helper()
+	var events []inputEvent
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		if !strings.HasPrefix(l, touchDevice) {
+			l = touchDevice + ": " + l
+		}
+		code, val, ok := parseEventLine(l)
+		if !ok {
+			t.Fatalf("bad event line %q", l)
+		}
+		events = append(events, inputEvent{code, val})
+	}
+	return events
+}
+
+// feed applies events straight to the engine, running a frame on every
+// SYN_REPORT, without the Run loop.
+func feed(e *gestureEngine, events []inputEvent) {
+	for _, ev := range events {
+		if e.contacts.Handle(ev.code, ev.val) {
+			e.Frame()
+		}
+	}
+}
+
+// fakeADB points adbPath at a shell script running body, with $state set
+// to a scratch directory that it returns.
+func fakeADB(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake adb is a shell script")
+	}
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "adb")
+	script := "#!/bin/sh\nstate=" + dir + "\n" + body + "\n"
+	if err := os.WriteFile(fake, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := adbPath
+	adbPath = fake
+	t.Cleanup(func() { adbPath = old })
+	return dir
+}
@@ -0,0 +1,47 @@
+package main
+
+// edgeScrollAxis returns 'v' or 'h' when a lone contact lands in one of the
+// active profile's edge-scroll strips, or 0 when it should act normally.
+func edgeScrollAxis(c *touchContact, pad digitizer) byte {
+	p := activeProfile()
+	if !p.edgeScroll() {
+		return 0
+	}
+	x, y := pad.Normalize(c.x, c.y)
+	z := p.EdgeScroll
+	switch {
+	case x < z.Left || x > 1-z.Right:
+		return 'v'
+	case y < z.Top || y > 1-z.Bottom:
+		return 'h'
+	}
+	return 0
+}
+
+// trackEdgeScroll scrolls along the contact's axis by its own movement,
+// with the same direction convention as two-finger scrolling.
+func (e *gestureEngine) trackEdgeScroll(c *touchContact) {
+	if c.fresh || !appInForeground.Load() {
+		return
+	}
+	if c.scrollAxis == 'v' {
+		e.scrollBy(0, float64(c.x-c.prevX)*cfg.Sensitivity)
+	} else {
+		e.scrollBy(float64(c.prevY-c.y)*cfg.Sensitivity, 0)
+	}
+}
+
+// scrollBy feeds pointer-scale deltas into the wheel accumulators and sends
+// whole notches once they build up.
+func (e *gestureEngine) scrollBy(dx, dy float64) {
+	e.scrollAccum += dy * 0.1
+	if e.scrollAccum >= 1.0 || e.scrollAccum <= -1.0 {
+		driver.Scroll(int32(e.scrollAccum * float64(cfg.ScrollSpeed)))
+		e.scrollAccum = 0
+	}
+	e.hScrollAccum += dx * 0.1
+	if e.hScrollAccum >= 1.0 || e.hScrollAccum <= -1.0 {
+		driver.HScroll(-int32(e.hScrollAccum * float64(cfg.ScrollSpeed)))
+		e.hScrollAccum = 0
+	}
+}
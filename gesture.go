@@ -0,0 +1,493 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+)
+
+// gestureEngine turns contact frames into pointer, button and gesture
+// output. All of its state, and every driver call it makes, belongs to the
+// goroutine running Run: timeouts are deadlines checked by that same loop
+// rather than callbacks on timer goroutines, so clicks, drags and moves are
+// emitted in a deterministic order.
+type gestureEngine struct {
+	contacts *contactTracker
+	pad      digitizer
+
+	touchStartTime  time.Time
+	lastReleaseTime time.Time
+	hasMoved        bool
+	holdDone        bool
+	isDragging      bool
+	lastTapWasPure  bool
+	longPressAt     time.Time // zero when no long press is pending
+	scrollAccum     float64
+	hScrollAccum    float64
+	motion          motionAccumulator
+	activeFingers   int
+	twoFinger       twoFingerState
+
+	dragLocked     bool      // button held between touches, see DragConfig.Lock
+	dragResumed    bool      // the current touch continues a locked drag
+	dragLockAt     time.Time // zero when no drag is locked
+	edgeNext       time.Time // next edge-motion step, zero when idle
+	edgeDX, edgeDY int32
+
+	timer *time.Timer // fires at the earliest of the deadlines above
+
+	// control carries functions from other goroutines (the control API)
+	// to run on the engine goroutine; done is closed when Run returns.
+	control chan func()
+	done    chan struct{}
+	paused  bool
+	mode    string // last proto mode sent to the app
+
+	// multiFinger is set once three or more fingers are down and stays set
+	// until the surface is clear, so lifting back to one or two fingers does
+	// not turn the tail of a swipe into scrolling or a tap.
+	multiFinger bool
+	swiped      bool
+
+	// heldKeys maps phone keys that are down to the mouse button they hold.
+	heldKeys map[string]string
+
+	// tapValid stays true while every finger of the current touch session
+	// has qualified as a tap; tapCount is how many of them lifted so far.
+	tapValid bool
+	tapCount int
+}
+
+// tapTravel is how far (pad space) a finger may drift and still tap.
+const tapTravel = 0.03
+
+// newGestureEngine returns an engine with no digitizer; Run sets pad from
+// the input source once it is open.
+func newGestureEngine() *gestureEngine {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	return &gestureEngine{
+		contacts: newContactTracker(),
+		timer:    timer,
+		mode:     proto.ModePointer,
+		heldKeys: make(map[string]string),
+		control:  make(chan func()),
+		done:     make(chan struct{}),
+	}
+}
+
+// inputEvent is one EV_ABS/EV_SYN code and value from the touch device.
+type inputEvent struct {
+	code string
+	val  int
+}
+
+// Run opens source and consumes its events until the stream ends,
+// interleaving them with the engine's own deadlines. Opening can take a
+// while, and the app source waits on the link, whose reader calls Do, so
+// Do calls are served from the start. Everything is released on return;
+// the error is from opening the source.
+func (e *gestureEngine) Run(source inputSource) error {
+	defer close(e.done)
+	defer e.Release()
+
+	type opened struct {
+		pad    digitizer
+		events <-chan inputEvent
+		err    error
+	}
+	ready := make(chan opened, 1)
+	go func() {
+		pad, events, err := source.Open()
+		ready <- opened{pad, events, err}
+	}()
+
+	var events <-chan inputEvent // nil until the source is open
+	for {
+		select {
+		case o := <-ready:
+			if o.err != nil {
+				return o.err
+			}
+			e.pad, events, ready = o.pad, o.events, nil
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if e.contacts.Handle(ev.code, ev.val) {
+				e.Frame()
+			}
+		case now := <-e.timer.C:
+			e.expire(now)
+		case fn := <-e.control:
+			fn()
+		}
+		e.schedule()
+		e.reportMode()
+	}
+}
+
+// Do runs fn on the engine goroutine and waits for it, which is how other
+// goroutines read or change engine state and cfg. It reports false if the
+// engine has already stopped.
+func (e *gestureEngine) Do(fn func()) bool {
+	finished := make(chan struct{})
+	select {
+	case e.control <- func() { fn(); close(finished) }:
+		<-finished
+		return true
+	case <-e.done:
+		return false
+	}
+}
+
+// SetPaused stops or restarts gesture output. Pausing lets go of anything
+// held, and fingers that are down at the time are ignored until lifted.
+func (e *gestureEngine) SetPaused(paused bool) {
+	if paused == e.paused {
+		return
+	}
+	e.paused = paused
+	if paused {
+		e.Release()
+		e.activeFingers = 0
+		e.multiFinger, e.swiped = false, false
+	}
+}
+
+// reportMode tells the app when what the engine is doing changes, so it
+// can show it.
+func (e *gestureEngine) reportMode() {
+	mode := proto.ModePointer
+	fingers := 0
+	for _, c := range e.contacts.Active() {
+		switch c.class {
+		case contactFinger:
+			fingers++
+		case contactEdgeScroll:
+			mode = proto.ModeScroll
+		}
+	}
+	switch {
+	case e.paused:
+		mode = proto.ModePaused
+	case e.isDragging || e.dragLocked:
+		mode = proto.ModeDrag
+	case e.multiFinger:
+		mode = proto.ModeGesture
+	case fingers >= 2 && e.twoFinger.mode == twoFingerScroll:
+		mode = proto.ModeScroll
+	case fingers >= 2 && e.twoFinger.mode == twoFingerPinch:
+		mode = proto.ModePinch
+	case fingers >= 2 && e.twoFinger.mode == twoFingerRotate:
+		mode = proto.ModeRotate
+	}
+	if mode != e.mode {
+		e.mode = mode
+		link.SetMode(mode)
+	}
+}
+
+// expire runs whatever deadlines have passed by now.
+func (e *gestureEngine) expire(now time.Time) {
+	if !e.longPressAt.IsZero() && !now.Before(e.longPressAt) {
+		e.longPressAt = time.Time{}
+		action, ok := binding("hold-" + strconv.Itoa(e.activeFingers))
+		if ok && !e.hasMoved && !e.holdDone && e.resting() {
+			action.Run()
+			haptic(hapticLongPress)
+			e.holdDone = true
+		}
+	}
+	if !e.dragLockAt.IsZero() && !now.Before(e.dragLockAt) {
+		e.dragLockAt = time.Time{}
+		e.dragLocked = false
+		e.endDrag()
+	}
+	if !e.edgeNext.IsZero() && !now.Before(e.edgeNext) {
+		driver.Move(e.edgeDX, e.edgeDY)
+		e.edgeNext = now.Add(edgeMotionTick)
+	}
+}
+
+// resting reports whether every finger on the pad is still within tap
+// travel of where it landed.
+func (e *gestureEngine) resting() bool {
+	for _, c := range e.contacts.Active() {
+		if c.counted && c.travel >= tapTravel {
+			return false
+		}
+	}
+	return true
+}
+
+// schedule points the timer at the earliest pending deadline.
+func (e *gestureEngine) schedule() {
+	var next time.Time
+	for _, t := range []time.Time{e.longPressAt, e.dragLockAt, e.edgeNext} {
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	if next.IsZero() {
+		e.timer.Stop()
+		return
+	}
+	e.timer.Reset(time.Until(next))
+}
+
+// Frame processes everything the tracker collected since the last frame.
+// Only contacts classified as fingers take part; a contact that turns out
+// to be a palm after going down is withdrawn without producing a tap.
+func (e *gestureEngine) Frame() {
+	t := e.contacts
+	all := t.Active()
+	if e.paused {
+		for _, c := range all {
+			c.class, c.counted = contactPalm, false
+		}
+		t.EndFrame()
+		return
+	}
+	classifyContacts(all, e.pad, t.now())
+	for _, c := range all {
+		if !c.fresh {
+			sx, sy := e.pad.Normalize(c.startX, c.startY)
+			x, y := e.pad.Normalize(c.x, c.y)
+			c.travel = math.Max(c.travel, math.Hypot(x-sx, y-sy))
+		}
+	}
+
+	var active []*touchContact
+	for _, c := range all {
+		if c.class == contactEdgeScroll {
+			e.trackEdgeScroll(c)
+			continue
+		}
+		if c.class == contactZone && c.pressed == "" {
+			if i := zoneAt(c, e.pad); i >= 0 {
+				c.pressed = cfg.Zones[i].Button
+				driver.Button(c.pressed, true)
+				haptic(hapticClick)
+			}
+		}
+		if c.class != contactFinger {
+			continue
+		}
+		if !c.counted {
+			c.counted = true
+			e.contactDown()
+		}
+		active = append(active, c)
+	}
+	for _, c := range t.lifted {
+		if c.pressed != "" {
+			driver.Button(c.pressed, false)
+		}
+		if c.counted {
+			e.contactUp(&c, true)
+		}
+	}
+	for _, c := range all {
+		if c.class == contactPalm && c.counted {
+			c.counted = false
+			e.contactUp(c, false)
+		}
+	}
+
+	if len(active) >= 3 && multiFingerBound() {
+		e.multiFinger = true
+	}
+
+	switch {
+	case e.multiFinger:
+		e.trackSwipe(active)
+	case appInForeground.Load():
+		e.trackMotion(active)
+	}
+
+	if e.isDragging && len(active) == 1 && appInForeground.Load() {
+		e.updateEdgeMotion(active[0])
+	} else {
+		e.stopEdgeMotion()
+	}
+
+	if len(active) == 0 {
+		e.multiFinger, e.swiped = false, false
+	}
+	t.EndFrame()
+}
+
+func (e *gestureEngine) contactDown() {
+	e.activeFingers++
+	if e.dragLocked {
+		e.resumeDrag()
+	} else if e.lastTapWasPure && time.Since(e.lastReleaseTime) < ms(cfg.DoubleTapTimeoutMs) {
+		e.isDragging = true
+		driver.Button("left", true)
+		haptic(hapticDragStart)
+	}
+	// Every new finger restarts the hold timeout, so hold-N measures how
+	// long all N fingers have rested together.
+	if !e.isDragging && anyBinding("hold-") {
+		e.longPressAt = time.Now().Add(ms(cfg.HoldTimeoutMs))
+	}
+	if e.activeFingers != 1 {
+		return
+	}
+
+	e.touchStartTime = time.Now()
+	e.hasMoved, e.holdDone = false, false
+	e.tapValid, e.tapCount = true, 0
+	e.motion.Reset()
+}
+
+// contactUp handles a finger leaving. lifted is false when the contact was
+// withdrawn as a palm, which must never count as a tap.
+//
+// Every contact is timed on its own: a touch session is a tap when each
+// finger that lifted did so within the tap timeout of landing and without
+// travelling, and the number of such fingers picks the tap-N binding.
+func (e *gestureEngine) contactUp(c *touchContact, lifted bool) {
+	if e.activeFingers > 0 {
+		e.activeFingers--
+	}
+	if lifted {
+		if time.Since(c.downAt) < ms(cfg.TapTimeoutMs) && c.travel < tapTravel {
+			e.tapCount++
+		} else {
+			e.tapValid = false
+		}
+	}
+
+	quick := lifted && !e.hasMoved && time.Since(e.touchStartTime) < ms(cfg.TapTimeoutMs)
+	endedByTap := false
+	if e.isDragging {
+		switch {
+		case e.dragResumed && quick:
+			// A tap is how a locked drag is ended; it must not click too.
+			endedByTap = true
+			e.endDrag()
+		case cfg.Drag.Lock && lifted && e.hasMoved && e.activeFingers == 0:
+			e.stopEdgeMotion()
+			e.lockDrag()
+		default:
+			e.endDrag()
+		}
+	}
+	e.lastReleaseTime = time.Now()
+	if e.activeFingers != 0 {
+		e.lastTapWasPure = false
+		return
+	}
+
+	e.stopLongPress()
+	tapped := e.tapValid && e.tapCount > 0 && !endedByTap && !e.hasMoved && !e.holdDone
+	e.lastTapWasPure = tapped && e.tapCount == 1
+	if !tapped {
+		return
+	}
+	if action, ok := binding("tap-" + strconv.Itoa(e.tapCount)); ok {
+		action.Run()
+		if action.Button != "" {
+			haptic(hapticClick)
+		}
+	}
+}
+
+// multiFingerBound reports whether any gesture needs three or more fingers
+// to be tracked together. When none is, extra fingers are simply ignored by
+// the one- and two-finger paths instead of suspending them.
+func multiFingerBound() bool {
+	return anyBinding("swipe-") ||
+		anyBinding("tap-3") || anyBinding("tap-4") ||
+		anyBinding("hold-3") || anyBinding("hold-4")
+}
+
+func (e *gestureEngine) stopLongPress() {
+	e.longPressAt = time.Time{}
+}
+
+// trackMotion moves the pointer with one finger and scrolls with two,
+// using the average delta of the contacts that were down last frame too.
+// Two-finger frames go through trackTwoFinger first, which may claim them
+// as pinch or rotate instead.
+func (e *gestureEngine) trackMotion(active []*touchContact) {
+	if len(active) >= 2 && !e.trackTwoFinger(active) {
+		return
+	}
+
+	var sumX, sumY, n float64
+	for _, c := range active {
+		if c.fresh {
+			continue
+		}
+		sumX += float64(c.x - c.prevX)
+		sumY += float64(c.y - c.prevY)
+		n++
+	}
+	if n == 0 {
+		return
+	}
+
+	dx, dy := e.motion.Add(-sumY/n, sumX/n, cfg.Sensitivity)
+	if dx == 0 && dy == 0 {
+		return
+	}
+	e.hasMoved = true
+	e.stopLongPress()
+
+	if len(active) >= 2 {
+		e.scrollBy(0, float64(dy))
+	} else {
+		driver.Move(dx, dy)
+	}
+}
+
+// trackSwipe fires a three- or four-finger swipe once the average
+// displacement of all contacts passes cfg.SwipeDistance.
+func (e *gestureEngine) trackSwipe(active []*touchContact) {
+	if e.swiped || len(active) < 3 {
+		return
+	}
+
+	var sumX, sumY float64
+	for _, c := range active {
+		if c.fresh {
+			return
+		}
+		sx, sy := e.pad.Normalize(c.startX, c.startY)
+		cx, cy := e.pad.Normalize(c.x, c.y)
+		sumX += cx - sx
+		sumY += cy - sy
+	}
+	n := float64(len(active))
+	dx, dy := sumX/n, sumY/n
+	if math.Hypot(dx, dy) < cfg.SwipeDistance {
+		return
+	}
+
+	e.swiped, e.hasMoved = true, true
+	fingers := "3"
+	if len(active) >= 4 {
+		fingers = "4"
+	}
+	if action, ok := binding("swipe-" + fingers + "-" + swipeDirection(dx, dy)); ok {
+		action.Run()
+	}
+}
+
+func swipeDirection(dx, dy float64) string {
+	if math.Abs(dx) > math.Abs(dy) {
+		if dx > 0 {
+			return "right"
+		}
+		return "left"
+	}
+	if dy > 0 {
+		return "down"
+	}
+	return "up"
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// runner drives an engine through Run on its own goroutine, the way
+// processInput does, so deadlines fire from the engine's timer.
+type runner struct {
+	t      *testing.T
+	e      *gestureEngine
+	f      *fakeDriver
+	events chan inputEvent
+}
+
+// chanSource is an input source on testPad that is open from the start
+// and reads its events from a channel.
+type chanSource chan inputEvent
+
+func (s chanSource) Open() (digitizer, <-chan inputEvent, error) { return testPad, s, nil }
+func (s chanSource) Close()                                      {}
+
+func startEngine(t *testing.T) *runner {
+	e, f := newTestEngine(t)
+	r := &runner{t: t, e: e, f: f, events: make(chan inputEvent)}
+	go e.Run(chanSource(r.events))
+	t.Cleanup(r.stop)
+	return r
+}
+
+func (r *runner) send(lines ...string) {
+	r.t.Helper()
+	for _, ev := range eventLines(r.t, lines...) {
+		r.events <- ev
+	}
+}
+
+func (r *runner) down(x, y int) {
+	r.send("EV_ABS ABS_MT_TRACKING_ID 00000001",
+		fmt.Sprintf("EV_ABS ABS_MT_POSITION_X %08x", x),
+		fmt.Sprintf("EV_ABS ABS_MT_POSITION_Y %08x", y),
+		"EV_ABS ABS_MT_TOUCH_MAJOR 0000001c",
+		"EV_SYN SYN_REPORT 00000000")
+}
+
+func (r *runner) move(x, y int) {
+	r.send(fmt.Sprintf("EV_ABS ABS_MT_POSITION_X %08x", x),
+		fmt.Sprintf("EV_ABS ABS_MT_POSITION_Y %08x", y),
+		"EV_SYN SYN_REPORT 00000000")
+}
+
+func (r *runner) up() {
+	r.send("EV_ABS ABS_MT_TRACKING_ID ffffffff", "EV_SYN SYN_REPORT 00000000")
+}
+
+// Multi-finger helpers return the lines for one contact slot; the caller
+// ends the frame with syn.
+const syn = "EV_SYN SYN_REPORT 00000000"
+
+func slotDown(slot, x, y int) []string {
+	return []string{fmt.Sprintf("EV_ABS ABS_MT_SLOT %08x", slot),
+		fmt.Sprintf("EV_ABS ABS_MT_TRACKING_ID %08x", 0x10+slot),
+		fmt.Sprintf("EV_ABS ABS_MT_POSITION_X %08x", x),
+		fmt.Sprintf("EV_ABS ABS_MT_POSITION_Y %08x", y),
+		"EV_ABS ABS_MT_TOUCH_MAJOR 0000001c"}
+}
+
+func slotMove(slot, x, y int) []string {
+	return []string{fmt.Sprintf("EV_ABS ABS_MT_SLOT %08x", slot),
+		fmt.Sprintf("EV_ABS ABS_MT_POSITION_X %08x", x),
+		fmt.Sprintf("EV_ABS ABS_MT_POSITION_Y %08x", y)}
+}
+
+func slotUp(slot int) []string {
+	return []string{fmt.Sprintf("EV_ABS ABS_MT_SLOT %08x", slot),
+		"EV_ABS ABS_MT_TRACKING_ID ffffffff"}
+}
+
+// frame sends the given slot lines as one frame.
+func (r *runner) frame(slots ...[]string) {
+	r.t.Helper()
+	r.send(append(slices.Concat(slots...), syn)...)
+}
+
+// expect waits for the engine to finish what it was sent and checks the
+// buttons and keys it produced so far.
+func (r *runner) expect(want ...string) {
+	r.t.Helper()
+	r.e.Do(func() {})
+	if got := r.f.Events(); !slices.Equal(got, want) {
+		r.t.Fatalf("events = %q, want %q", got, want)
+	}
+}
+
+func (r *runner) stop() {
+	select {
+	case <-r.e.done:
+		return
+	default:
+	}
+	close(r.events)
+	<-r.e.done
+}
+
+// slowSource is an input source whose Open waits until it is given the
+// result.
+type slowSource struct {
+	result chan error
+	events chan inputEvent
+}
+
+func (s slowSource) Open() (digitizer, <-chan inputEvent, error) {
+	if err := <-s.result; err != nil {
+		return digitizer{}, nil, err
+	}
+	return testPad, s.events, nil
+}
+
+func (s slowSource) Close() {}
+
+func TestRunWhileOpening(t *testing.T) {
+	ran := func(e *gestureEngine) bool {
+		t.Helper()
+		ok := make(chan bool, 1)
+		go func() { ok <- e.Do(func() {}) }()
+		select {
+		case v := <-ok:
+			return v
+		case <-time.After(time.Second):
+			t.Fatal("Do blocked")
+			return false
+		}
+	}
+
+	t.Run("opens", func(t *testing.T) {
+		e, f := newTestEngine(t)
+		e.pad = digitizer{}
+		src := slowSource{make(chan error), make(chan inputEvent)}
+		go e.Run(src)
+		if !ran(e) {
+			t.Fatal("Do did not run while the source was opening")
+		}
+		src.result <- nil
+		lines := slices.Concat(slotDown(0, 540, 1200), []string{syn},
+			slotMove(0, 540, 1100), []string{syn}, slotUp(0), []string{syn})
+		for _, ev := range eventLines(t, lines...) {
+			src.events <- ev
+		}
+		close(src.events)
+		<-e.done
+		if e.pad != testPad {
+			t.Errorf("pad = %+v, want the source's %+v", e.pad, testPad)
+		}
+		if x, y := f.Motion(); x == 0 && y == 0 {
+			t.Error("events after opening did not move the pointer")
+		}
+	})
+
+	t.Run("fails", func(t *testing.T) {
+		e, _ := newTestEngine(t)
+		src := slowSource{make(chan error), nil}
+		failed := make(chan error, 1)
+		go func() { failed <- e.Run(src) }()
+		if !ran(e) {
+			t.Fatal("Do did not run while the source was opening")
+		}
+		src.result <- errSourceClosed
+		if err := <-failed; err != errSourceClosed {
+			t.Fatalf("Run = %v, want %v", err, errSourceClosed)
+		}
+		// The link and control API must not hang on an engine that never
+		// started.
+		if ran(e) {
+			t.Error("Do ran after Run returned")
+		}
+	})
+}
+
+func TestRunTapAndHold(t *testing.T) {
+	t.Run("tap", func(t *testing.T) {
+		r := startEngine(t)
+		r.down(540, 1200)
+		r.up()
+		r.expect("left down", "left up")
+	})
+
+	t.Run("hold", func(t *testing.T) {
+		r := startEngine(t)
+		cfg.HoldTimeoutMs = 30
+		r.down(540, 1200)
+		r.expect()
+		time.Sleep(80 * time.Millisecond)
+		// The hold fires from the timer while the finger is still down.
+		r.expect("right down", "right up")
+		r.up()
+		r.expect("right down", "right up")
+	})
+
+	t.Run("move cancels hold", func(t *testing.T) {
+		r := startEngine(t)
+		cfg.HoldTimeoutMs = 30
+		r.down(540, 1200)
+		for i := 1; i <= 5; i++ {
+			r.move(540+10*i, 1200-40*i)
+		}
+		time.Sleep(80 * time.Millisecond)
+		r.up()
+		r.expect()
+		if x, y := r.f.Motion(); x == 0 && y == 0 {
+			t.Error("the finger did not move the pointer")
+		}
+	})
+}
+
+func TestRunMultiFingerTap(t *testing.T) {
+	t.Run("two fingers", func(t *testing.T) {
+		r := startEngine(t)
+		r.frame(slotDown(0, 540, 1100), slotDown(1, 540, 1350))
+		r.frame(slotUp(0), slotUp(1))
+		r.expect("right down", "right up")
+	})
+
+	t.Run("three fingers", func(t *testing.T) {
+		r := startEngine(t)
+		r.frame(slotDown(0, 540, 900), slotDown(1, 540, 1150), slotDown(2, 540, 1400))
+		r.frame(slotUp(0), slotUp(1), slotUp(2))
+		r.expect("middle down", "middle up")
+	})
+
+	t.Run("fingers land and lift apart", func(t *testing.T) {
+		// Each finger is timed on its own, so a staggered tap still
+		// counts every finger.
+		r := startEngine(t)
+		r.frame(slotDown(0, 540, 1100))
+		r.frame(slotDown(1, 540, 1350))
+		r.frame(slotUp(0))
+		r.frame(slotUp(1))
+		r.expect("right down", "right up")
+	})
+
+	t.Run("finger lifts late", func(t *testing.T) {
+		r := startEngine(t)
+		cfg.TapTimeoutMs = 40
+		r.frame(slotDown(0, 540, 1100), slotDown(1, 540, 1350))
+		r.frame(slotUp(0))
+		time.Sleep(80 * time.Millisecond)
+		r.frame(slotUp(1))
+		r.expect()
+	})
+
+	t.Run("one of three lifts late", func(t *testing.T) {
+		r := startEngine(t)
+		cfg.TapTimeoutMs = 40
+		r.frame(slotDown(0, 540, 900), slotDown(1, 540, 1150), slotDown(2, 540, 1400))
+		r.frame(slotUp(0), slotUp(1))
+		time.Sleep(80 * time.Millisecond)
+		r.frame(slotUp(2))
+		r.expect()
+	})
+
+	t.Run("finger travels", func(t *testing.T) {
+		// Too little for the pair to scroll or pinch, so only the finger's
+		// own travel tells this from a tap.
+		r := startEngine(t)
+		r.frame(slotDown(0, 540, 700), slotDown(1, 540, 1700))
+		for i := 1; i <= 5; i++ {
+			r.frame(slotMove(1, 540+11*i, 1700))
+		}
+		r.frame(slotUp(0), slotUp(1))
+		r.expect()
+	})
+
+	t.Run("one of three travels", func(t *testing.T) {
+		r := startEngine(t)
+		r.frame(slotDown(0, 540, 900), slotDown(1, 540, 1150), slotDown(2, 540, 1400))
+		for i := 1; i <= 5; i++ {
+			r.frame(slotMove(2, 540+20*i, 1400))
+		}
+		r.frame(slotUp(0), slotUp(1), slotUp(2))
+		r.expect()
+	})
+}
+
+func TestRunDrag(t *testing.T) {
+	t.Run("double tap drag", func(t *testing.T) {
+		r := startEngine(t)
+		r.down(540, 1200)
+		r.up()
+		r.down(540, 1200)
+		r.expect("left down", "left up", "left down")
+		for i := 1; i <= 5; i++ {
+			r.move(540, 1200-30*i)
+		}
+		r.up()
+		r.expect("left down", "left up", "left down", "left up")
+	})
+
+	t.Run("locked drag times out", func(t *testing.T) {
+		r := startEngine(t)
+		cfg.Drag.Lock, cfg.Drag.LockTimeoutMs = true, 40
+		r.down(540, 1200)
+		r.up()
+		r.down(540, 1200)
+		for i := 1; i <= 5; i++ {
+			r.move(540, 1200-30*i)
+		}
+		r.up()
+		// Still held after the lift, released by the lock timeout.
+		r.expect("left down", "left up", "left down")
+		time.Sleep(100 * time.Millisecond)
+		r.expect("left down", "left up", "left down", "left up")
+	})
+
+	t.Run("tap ends locked drag", func(t *testing.T) {
+		r := startEngine(t)
+		cfg.Drag.Lock, cfg.Drag.LockTimeoutMs = true, 5000
+		r.down(540, 1200)
+		r.up()
+		r.down(540, 1200)
+		for i := 1; i <= 5; i++ {
+			r.move(540, 1200-30*i)
+		}
+		r.up()
+		r.down(540, 1000)
+		r.up()
+		// The tap releases the drag without clicking again.
+		r.expect("left down", "left up", "left down", "left up")
+	})
+
+	t.Run("edge motion while resting", func(t *testing.T) {
+		r := startEngine(t)
+		r.down(540, 1200)
+		r.up()
+		r.down(540, 1200)
+		for i := 1; i <= 10; i++ {
+			r.move(540, 1200+113*i) // towards the pad's left edge
+		}
+		r.e.Do(func() {})
+		before, _ := r.f.Motion()
+		time.Sleep(100 * time.Millisecond)
+		r.e.Do(func() {})
+		after, _ := r.f.Motion()
+		if after >= before {
+			t.Errorf("pointer x went from %d to %d while resting at the left edge", before, after)
+		}
+		r.up()
+		r.expect("left down", "left up", "left down", "left up")
+		r.e.Do(func() {})
+		stopped, _ := r.f.Motion()
+		time.Sleep(50 * time.Millisecond)
+		r.e.Do(func() {})
+		if end, _ := r.f.Motion(); end != stopped {
+			t.Errorf("edge motion went on after the lift: x %d -> %d", stopped, end)
+		}
+	})
+}
+
+func TestRunPauseReleases(t *testing.T) {
+	r := startEngine(t)
+	r.down(540, 1200)
+	r.up()
+	r.down(540, 1200)
+	r.expect("left down", "left up", "left down")
+	r.e.Do(func() { r.e.SetPaused(true) })
+	r.expect("left down", "left up", "left down", "left up")
+
+	// Nothing comes through while paused, including the finger that was
+	// down when pausing.
+	r.move(540, 1000)
+	r.up()
+	r.down(540, 1200)
+	r.up()
+	r.expect("left down", "left up", "left down", "left up")
+	if x, y := r.f.Motion(); x != 0 || y != 0 {
+		t.Errorf("moved (%d, %d) while paused", x, y)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+)
+
+// Haptic events. Each has its own pattern, so a long-press click and the
+// start and end of a drag feel different from a plain click.
+const (
+	hapticClick     = "click"
+	hapticLongPress = "long_press"
+	hapticDragStart = "drag_start"
+	hapticDragEnd   = "drag_end"
+)
+
+// hapticPatterns are Android waveform timings in milliseconds: pauses and
+// vibrations alternating, starting with a pause.
+var hapticPatterns = map[string][]int64{
+	hapticClick:     {0, 12},
+	hapticLongPress: {0, 25, 60, 25},
+	hapticDragStart: {0, 8, 30, 30},
+	hapticDragEnd:   {0, 30},
+}
+
+// HapticConfig is the vibration strength for each haptic event, from 0 to
+// 1. Zero turns that event off.
+type HapticConfig struct {
+	Click     float64 `json:"click"`
+	LongPress float64 `json:"long_press"`
+	DragStart float64 `json:"drag_start"`
+	DragEnd   float64 `json:"drag_end"`
+}
+
+func (h HapticConfig) strength(event string) float64 {
+	switch event {
+	case hapticClick:
+		return h.Click
+	case hapticLongPress:
+		return h.LongPress
+	case hapticDragStart:
+		return h.DragStart
+	case hapticDragEnd:
+		return h.DragEnd
+	}
+	return 0
+}
+
+// adbVibrating is set while the adb fallback in haptic is running.
+var adbVibrating atomic.Bool
+
+// haptic vibrates the phone for event without blocking the engine. The app
+// plays the pattern at the configured strength; while it is not connected,
+// adb can still vibrate once, but with neither pattern nor strength.
+func haptic(event string) {
+	s := math.Min(cfg.Haptics.strength(event), 1)
+	if s <= 0 {
+		return
+	}
+	pattern := hapticPatterns[event]
+	if link.connected.Load() {
+		amplitude := max(1, int(math.Round(s*255)))
+		link.Send(proto.Message{Type: proto.Haptic, Pattern: pattern, Amplitude: amplitude})
+		return
+	}
+
+	// Each adb call takes far longer than the buzz, so events that come in
+	// while one is running are dropped rather than queued up behind it.
+	if !adbVibrating.CompareAndSwap(false, true) {
+		return
+	}
+	var total int64
+	for i := 1; i < len(pattern); i += 2 {
+		total += pattern[i]
+	}
+	go func() {
+		defer adbVibrating.Store(false)
+		runADB("shell", "cmd", "vibrator", "vibrate", strconv.FormatInt(total, 10))
+	}()
+}
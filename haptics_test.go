@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHapticFallbackOneAtATime(t *testing.T) {
+	newTestEngine(t)
+	cfg.Haptics = defaultConfig().Haptics
+	link.connected.Store(false)
+
+	// A slow adb that logs each call.
+	dir := fakeADB(t, `echo "$*" >> "$state/calls"; sleep 0.3`)
+	calls := filepath.Join(dir, "calls")
+
+	for range 20 {
+		haptic(hapticClick)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for adbVibrating.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("fallback still running")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 || lines[0] != "shell cmd vibrator vibrate 12" {
+		t.Errorf("adb ran %q, want one vibrate", lines)
+	}
+
+	// Once it has finished, the next event vibrates again.
+	haptic(hapticLongPress)
+	for adbVibrating.Load() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	data, _ = os.ReadFile(calls)
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("adb ran %d times, want 2", n)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// touchContact is a single finger tracked through a multitouch slot.
+// Positions are raw digitizer units in the phone's natural orientation.
+type touchContact struct {
+	id             int
+	x, y           int
+	prevX, prevY   int
+	startX, startY int
+	major          int // ABS_MT_TOUCH_MAJOR, 0 if unsupported
+	pressure       int // ABS_MT_PRESSURE, 0 if unsupported
+	downAt         time.Time
+	fresh          bool    // landed during the frame being assembled
+	travel         float64 // furthest distance from the start, pad space
+
+	class      contactClass // set by classifyContacts
+	counted    bool         // the engine has seen this contact go down
+	pressed    string       // button held by a zone contact, "" if none
+	scrollAxis byte         // 'v' or 'h' for edge-scroll contacts
+}
+
+// slotValues are the last ABS_MT_* values reported for a slot. The kernel
+// drops updates that repeat them, so new contacts start from here.
+type slotValues struct {
+	x, y, major, pressure int
+}
+
+// contactTracker assembles getevent lines into per-slot contacts using the
+// type B multitouch protocol (ABS_MT_SLOT / ABS_MT_TRACKING_ID). Devices that
+// never send ABS_MT_SLOT simply report everything in slot 0.
+type contactTracker struct {
+	slot   int
+	slots  map[int]*touchContact
+	values map[int]*slotValues
+	order  []int          // active slots in landing order
+	landed []int          // slots that went down in the current frame
+	lifted []touchContact // contacts that went up in the current frame
+
+	// now stamps landings and frames. It is time.Now except when a
+	// recording is replayed with its own timestamps.
+	now func() time.Time
+}
+
+func newContactTracker() *contactTracker {
+	return &contactTracker{slots: make(map[int]*touchContact), values: make(map[int]*slotValues), now: time.Now}
+}
+
+// Handle applies one EV_ABS code/value pair. It reports true when the
+// value closed a frame (SYN_REPORT) and the engine should run.
+func (t *contactTracker) Handle(code string, val int) bool {
+	switch code {
+	case "ABS_MT_SLOT":
+		t.slot = val
+	case "ABS_MT_TRACKING_ID":
+		if val < 0 {
+			t.lift(t.slot)
+		} else {
+			t.land(t.slot, val)
+		}
+	case "ABS_MT_POSITION_X":
+		t.slotValues().x = val
+	case "ABS_MT_POSITION_Y":
+		t.slotValues().y = val
+	case "ABS_MT_TOUCH_MAJOR":
+		t.slotValues().major = val
+	case "ABS_MT_PRESSURE":
+		t.slotValues().pressure = val
+	case "SYN_REPORT":
+		for slot, c := range t.slots {
+			v := t.values[slot]
+			c.x, c.y, c.major, c.pressure = v.x, v.y, v.major, v.pressure
+		}
+		return true
+	}
+	return false
+}
+
+func (t *contactTracker) slotValues() *slotValues {
+	v, ok := t.values[t.slot]
+	if !ok {
+		v = &slotValues{}
+		t.values[t.slot] = v
+	}
+	return v
+}
+
+func (t *contactTracker) land(slot, id int) {
+	if _, ok := t.slots[slot]; ok {
+		t.lift(slot)
+	}
+	t.slotValues()
+	t.slots[slot] = &touchContact{id: id, downAt: t.now(), fresh: true}
+	t.order = append(t.order, slot)
+	t.landed = append(t.landed, slot)
+}
+
+func (t *contactTracker) lift(slot int) {
+	c, ok := t.slots[slot]
+	if !ok {
+		return
+	}
+	delete(t.slots, slot)
+	for i, s := range t.order {
+		if s == slot {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			break
+		}
+	}
+	t.lifted = append(t.lifted, *c)
+}
+
+// Active returns the contacts currently on the surface in landing order.
+func (t *contactTracker) Active() []*touchContact {
+	out := make([]*touchContact, 0, len(t.order))
+	for _, s := range t.order {
+		out = append(out, t.slots[s])
+	}
+	return out
+}
+
+// EndFrame rolls positions over to the next frame and clears the per-frame
+// landed/lifted lists. Fresh contacts get their starting point here because
+// their coordinates arrive after the tracking ID within the same frame.
+func (t *contactTracker) EndFrame() {
+	for _, c := range t.slots {
+		if c.fresh {
+			c.startX, c.startY = c.x, c.y
+			c.prevX, c.prevY = c.x, c.y
+			c.fresh = false
+			continue
+		}
+		c.prevX, c.prevY = c.x, c.y
+	}
+	t.landed = t.landed[:0]
+	t.lifted = t.lifted[:0]
+}
+
+// parseEventLine splits a `getevent -l` line from touchDevice into its
+// code and value. Key states (DOWN/UP) are reported as 1/0.
+func parseEventLine(line string) (code string, val int, ok bool) {
+	rest, found := strings.CutPrefix(line, touchDevice+":")
+	if !found {
+		return "", 0, false
+	}
+	fields := strings.Fields(rest)
+	if len(fields) != 3 {
+		return "", 0, false
+	}
+	switch fields[2] {
+	case "DOWN":
+		return fields[1], 1, true
+	case "UP":
+		return fields[1], 0, true
+	}
+	v, err := strconv.ParseUint(fields[2], 16, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return fields[1], int(int32(v)), true
+}
+
+// digitizer holds the coordinate ranges of the touch device so gestures
+// can work in resolution-independent pad space.
+type digitizer struct {
+	maxX, maxY  int
+	maxMajor    int // 0 when the device does not report contact size
+	maxPressure int // 0 when the device does not report pressure
+}
+
+// Normalize maps a raw position into pad space: 0..1 on both axes as the
+// user sees the phone in the landscape orientation set by setupEnvironment.
+func (d digitizer) Normalize(x, y int) (float64, float64) {
+	return float64(d.maxY-y) / float64(d.maxY), float64(x) / float64(d.maxX)
+}
+
+func probeDigitizer() digitizer {
+	d := digitizer{maxX: 1080, maxY: 2400}
+	out, err := exec.Command(adbPath, "shell", "getevent", "-lp", touchDevice).Output()
+	if err != nil {
+		fmt.Printf("[!] Could not read digitizer ranges, assuming %dx%d\n", d.maxX, d.maxY)
+		return d
+	}
+	reMax := regexp.MustCompile(`(ABS_MT_POSITION_X|ABS_MT_POSITION_Y|ABS_MT_TOUCH_MAJOR|ABS_MT_PRESSURE)\s*:.*max (\d+)`)
+	for _, m := range reMax.FindAllStringSubmatch(string(out), -1) {
+		v, _ := strconv.Atoi(m[2])
+		if v <= 0 {
+			continue
+		}
+		switch m[1] {
+		case "ABS_MT_POSITION_X":
+			d.maxX = v
+		case "ABS_MT_POSITION_Y":
+			d.maxY = v
+		case "ABS_MT_TOUCH_MAJOR":
+			d.maxMajor = v
+		case "ABS_MT_PRESSURE":
+			d.maxPressure = v
+		}
+	}
+	return d
+}
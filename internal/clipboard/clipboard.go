@@ -0,0 +1,10 @@
+// Package clipboard reads and writes the desktop clipboard as plain text.
+// On Linux it runs wl-clipboard under Wayland and xclip under X11; on
+// Windows it uses the Win32 clipboard directly.
+package clipboard
+
+import "errors"
+
+// ErrNoText is returned by Read when the clipboard holds no text, for
+// example an image.
+var ErrNoText = errors.New("clipboard holds no text")
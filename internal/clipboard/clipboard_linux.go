@@ -0,0 +1,45 @@
+//go:build linux
+
+package clipboard
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func wayland() bool {
+	return os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// Read returns the text on the clipboard.
+func Read() (string, error) {
+	var cmd *exec.Cmd
+	if wayland() {
+		cmd = exec.Command("wl-paste", "--no-newline", "--type", "text/plain")
+	} else {
+		cmd = exec.Command("xclip", "-o", "-selection", "clipboard", "-t", "UTF8_STRING")
+	}
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		// Both tools fail when there is nothing they can give as text.
+		return "", ErrNoText
+	}
+	return string(out), err
+}
+
+// Write puts text on the clipboard.
+func Write(text string) error {
+	var cmd *exec.Cmd
+	if wayland() {
+		cmd = exec.Command("wl-copy", "--type", "text/plain;charset=utf-8")
+	} else {
+		cmd = exec.Command("xclip", "-i", "-selection", "clipboard", "-t", "UTF8_STRING")
+	}
+	// Both tools stay in the background to serve the selection, so their
+	// output must not be waited on.
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
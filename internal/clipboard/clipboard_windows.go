@@ -0,0 +1,109 @@
+//go:build windows
+
+package clipboard
+
+import (
+	"errors"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	cfUnicodeText = 13
+	gmemMoveable  = 0x0002
+)
+
+var (
+	user32   = syscall.NewLazyDLL("user32.dll")
+	kernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	openClipboard    = user32.NewProc("OpenClipboard")
+	closeClipboard   = user32.NewProc("CloseClipboard")
+	emptyClipboard   = user32.NewProc("EmptyClipboard")
+	getClipboardData = user32.NewProc("GetClipboardData")
+	setClipboardData = user32.NewProc("SetClipboardData")
+	isFormatAvail    = user32.NewProc("IsClipboardFormatAvailable")
+
+	globalAlloc  = kernel32.NewProc("GlobalAlloc")
+	globalFree   = kernel32.NewProc("GlobalFree")
+	globalLock   = kernel32.NewProc("GlobalLock")
+	globalUnlock = kernel32.NewProc("GlobalUnlock")
+	lstrlenW     = kernel32.NewProc("lstrlenW")
+	moveMemory   = kernel32.NewProc("RtlMoveMemory")
+)
+
+// open opens the clipboard, retrying briefly because another program may
+// be holding it.
+func open() error {
+	for range 10 {
+		if r, _, _ := openClipboard.Call(0); r != 0 {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return errors.New("clipboard is busy")
+}
+
+// Read returns the text on the clipboard.
+func Read() (string, error) {
+	if r, _, _ := isFormatAvail.Call(cfUnicodeText); r == 0 {
+		return "", ErrNoText
+	}
+	if err := open(); err != nil {
+		return "", err
+	}
+	defer closeClipboard.Call()
+
+	h, _, err := getClipboardData.Call(cfUnicodeText)
+	if h == 0 {
+		return "", err
+	}
+	p, _, err := globalLock.Call(h)
+	if p == 0 {
+		return "", err
+	}
+	defer globalUnlock.Call(h)
+
+	// The data is a NUL-terminated UTF-16 string.
+	n, _, _ := lstrlenW.Call(p)
+	if n == 0 {
+		return "", nil
+	}
+	text := make([]uint16, n)
+	moveMemory.Call(uintptr(unsafe.Pointer(&text[0])), p, n*2)
+	return syscall.UTF16ToString(text), nil
+}
+
+// Write puts text on the clipboard.
+func Write(text string) error {
+	data, err := syscall.UTF16FromString(text)
+	if err != nil {
+		return err
+	}
+	if err := open(); err != nil {
+		return err
+	}
+	defer closeClipboard.Call()
+	emptyClipboard.Call()
+
+	size := uintptr(len(data) * 2)
+	h, _, err := globalAlloc.Call(gmemMoveable, size)
+	if h == 0 {
+		return err
+	}
+	p, _, err := globalLock.Call(h)
+	if p == 0 {
+		globalFree.Call(h)
+		return err
+	}
+	moveMemory.Call(p, uintptr(unsafe.Pointer(&data[0])), size)
+	globalUnlock.Call(h)
+
+	// On success the clipboard owns the memory.
+	if r, _, err := setClipboardData.Call(cfUnicodeText, h); r == 0 {
+		globalFree.Call(h)
+		return err
+	}
+	return nil
+}
@@ -5,5 +5,24 @@ type MouseDriver interface {
 	Move(dx, dy int32)
 	Button(button string, down bool)
 	Scroll(delta int32)
+	HScroll(delta int32)
 	Close()
 }
+
+// KeyboardDriver sends key presses and text. Chords are built on top of it with
+// ParseChord and Chord.Press.
+type KeyboardDriver interface {
+	Key(k Key, down bool)
+
+	// Type enters text as if typed and returns how many characters it
+	// could not produce.
+	Type(text string) int
+	Close()
+}
+
+// Driver is the virtual input device returned by InitDriver. Mouse and
+// keyboard output share one device, so one Close tears both down.
+type Driver interface {
+	MouseDriver
+	KeyboardDriver
+}
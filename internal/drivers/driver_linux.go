@@ -3,88 +3,129 @@
 package drivers
 
 import (
-	"encoding/binary"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"syscall"
+	"unsafe"
 )
 
-// SKEPTICAL FIX: Using a platform-agnostic way to handle the timeval padding
-type linuxInputEvent struct {
-	Time  syscall.Timeval
-	Type  uint16
-	Code  uint16
-	Value int32
-}
-
 type LinuxDriver struct {
 	file *os.File
 }
 
-func InitDriver() MouseDriver {
-	f, err := os.OpenFile("/dev/uinput", os.O_WRONLY|syscall.O_NONBLOCK, 0660)
+// UinputPath is the device node virtual input devices are created through.
+const UinputPath = "/dev/uinput"
+
+// WheelNotch is the Scroll delta of one wheel click. REL_WHEEL counts
+// whole clicks.
+const WheelNotch = 1
+
+// InitDriver creates the virtual mouse and keyboard. Its errors say what
+// is wrong with the system's uinput setup and how to fix it.
+func InitDriver(id Identity) (Driver, error) {
+	f, err := os.OpenFile(UinputPath, os.O_WRONLY|syscall.O_NONBLOCK, 0660)
 	if err != nil {
-		fmt.Println("[-] Error: uinput access denied. Try: sudo usermod -aG input $USER")
-		os.Exit(1)
+		return nil, uinputOpenError(err)
 	}
 
 	// Constants for uinput
 	const (
-		UI_SET_EVBIT  = 0x40045564
-		UI_SET_KEYBIT = 0x40045565
-		UI_SET_RELBIT = 0x40045566
-		UI_DEV_SETUP  = 0x405c5503
-		UI_DEV_CREATE = 0x5501
-
-		EV_KEY    = 0x01
-		EV_REL    = 0x02
-		BTN_LEFT  = 0x110
-		BTN_RIGHT = 0x111
-		REL_X     = 0x00
-		REL_Y     = 0x01
-		REL_WHEEL = 0x08
+		EV_KEY     = 0x01
+		EV_REL     = 0x02
+		BTN_LEFT   = 0x110
+		BTN_RIGHT  = 0x111
+		BTN_MIDDLE = 0x112
+		REL_X      = 0x00
+		REL_Y      = 0x01
+		REL_HWHEEL = 0x06
+		REL_WHEEL  = 0x08
 	)
 
 	// Setup bits
-	ioctl(f.Fd(), UI_SET_EVBIT, EV_KEY)
-	ioctl(f.Fd(), UI_SET_EVBIT, EV_REL)
-	ioctl(f.Fd(), UI_SET_KEYBIT, BTN_LEFT)
-	ioctl(f.Fd(), UI_SET_KEYBIT, BTN_RIGHT)
-	ioctl(f.Fd(), UI_SET_RELBIT, REL_X)
-	ioctl(f.Fd(), UI_SET_RELBIT, REL_Y)
-	ioctl(f.Fd(), UI_SET_RELBIT, REL_WHEEL)
-
-	// Modern Setup (UI_DEV_SETUP)
-	// We define the struct locally to ensure correct padding
-	type uinputSetup struct {
-		ID struct {
-			Bustype, Vendor, Product, Version uint16
+	bits := [][2]uintptr{
+		{uiSetEvBit, EV_KEY},
+		{uiSetEvBit, EV_REL},
+		{uiSetKeyBit, BTN_LEFT},
+		{uiSetKeyBit, BTN_RIGHT},
+		{uiSetKeyBit, BTN_MIDDLE},
+		{uiSetRelBit, REL_X},
+		{uiSetRelBit, REL_Y},
+		{uiSetRelBit, REL_WHEEL},
+		{uiSetRelBit, REL_HWHEEL},
+	}
+	for _, k := range keyTable {
+		bits = append(bits, [2]uintptr{uiSetKeyBit, uintptr(k.linux)})
+	}
+	for _, b := range bits {
+		if err := ioctl(f.Fd(), b[0], b[1]); err != nil {
+			f.Close()
+			return nil, uinputError("configure", err)
 		}
-		Name [80]byte
-		_    uint32 // ff_effects_max padding
 	}
 
+	// Modern Setup (UI_DEV_SETUP)
 	setup := uinputSetup{}
-	setup.ID.Bustype = 0x03 // BUS_USB
-	setup.ID.Vendor = 0x1234
-	setup.ID.Product = 0x5678
-	copy(setup.Name[:], "Sponge Virtual Mouse")
+	setup.ID.Bustype = id.Bus
+	setup.ID.Vendor = id.Vendor
+	setup.ID.Product = id.Product
+	setup.ID.Version = id.Version
+	// The name must leave room for its NUL terminator.
+	copy(setup.Name[:len(setup.Name)-1], id.Name)
+
+	// The struct is passed to the ioctl; a plain write() only accepts the
+	// legacy uinput_user_dev layout.
+	if err := ioctlPtr(f.Fd(), uiDevSetup, unsafe.Pointer(&setup)); err != nil {
+		f.Close()
+		return nil, uinputError("set up", err)
+	}
+	if err := ioctl(f.Fd(), uiDevCreate, 0); err != nil {
+		f.Close()
+		return nil, uinputError("create", err)
+	}
+
+	return &LinuxDriver{file: f}, nil
+}
 
-	// Write setup info
-	binary.Write(f, binary.LittleEndian, &setup)
-	ioctl(f.Fd(), UI_DEV_CREATE, 0)
+// uinputOpenError explains why UinputPath could not be opened.
+func uinputOpenError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if _, serr := os.Stat("/sys/class/misc/uinput"); serr == nil {
+			return fmt.Errorf("%s is missing although the uinput module is loaded; udev did not create the node (%w)", UinputPath, err)
+		}
+		return fmt.Errorf("%s is missing: the uinput kernel module is not loaded (try: sudo modprobe uinput) (%w)", UinputPath, err)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("no permission to open %s (run: sudo touchpad-tool setup-linux) (%w)", UinputPath, err)
+	case errors.Is(err, syscall.EBUSY):
+		return fmt.Errorf("%s is busy; another program holds it exclusively (%w)", UinputPath, err)
+	}
+	return fmt.Errorf("open %s: %w", UinputPath, err)
+}
 
-	return &LinuxDriver{file: f}
+// uinputError explains a failed uinput ioctl.
+func uinputError(op string, err error) error {
+	switch {
+	case errors.Is(err, syscall.EINVAL) && op == "set up":
+		return fmt.Errorf("could not set up the virtual device: the kernel does not support UI_DEV_SETUP (needs Linux 4.5 or later) (%w)", err)
+	case errors.Is(err, syscall.EBUSY):
+		return fmt.Errorf("could not %s the virtual device: uinput is busy (%w)", op, err)
+	case errors.Is(err, syscall.ENOMEM):
+		return fmt.Errorf("could not %s the virtual device: out of memory (%w)", op, err)
+	}
+	return fmt.Errorf("could not %s the virtual device: %w", op, err)
 }
 
 func (l *LinuxDriver) WriteEvent(typ, code uint16, val int32) {
-	ev := linuxInputEvent{
+	ev := inputEvent{
 		Type:  typ,
 		Code:  code,
 		Value: val,
 	}
 	// Note: We don't actually need to set Time.Sec/Usec; the kernel fills them.
-	binary.Write(l.file, binary.LittleEndian, &ev)
+	// The struct is written as laid out in memory, which is the kernel's layout.
+	l.file.Write(unsafe.Slice((*byte)(unsafe.Pointer(&ev)), unsafe.Sizeof(ev)))
 }
 
 func (l *LinuxDriver) Move(dx, dy int32) {
@@ -99,8 +140,11 @@ func (l *LinuxDriver) Button(b string, down bool) {
 		val = 1
 	}
 	code := uint16(0x110)
-	if b == "right" {
+	switch b {
+	case "right":
 		code = 0x111
+	case "middle":
+		code = 0x112
 	}
 
 	l.WriteEvent(0x01, code, val)
@@ -114,11 +158,36 @@ func (l *LinuxDriver) Scroll(d int32) {
 	l.WriteEvent(0x00, 0x00, 0)
 }
 
-func (l *LinuxDriver) Close() {
-	ioctl(l.file.Fd(), 0x5502, 0) // UI_DEV_DESTROY
-	l.file.Close()
+func (l *LinuxDriver) Key(k Key, down bool) {
+	var val int32
+	if down {
+		val = 1
+	}
+	l.WriteEvent(0x01, k.linux, val)
+	l.WriteEvent(0x00, 0x00, 0)
+}
+
+// Type presses the keys for each character on a US layout. A virtual
+// keyboard has no way to enter other characters, so they are skipped.
+func (l *LinuxDriver) Type(text string) int {
+	skipped := 0
+	for _, r := range text {
+		c, ok := RuneChord(r)
+		if !ok {
+			skipped++
+			continue
+		}
+		c.Press(l)
+	}
+	return skipped
+}
+
+func (l *LinuxDriver) HScroll(d int32) {
+	l.WriteEvent(0x02, 0x06, d) // REL_HWHEEL
+	l.WriteEvent(0x00, 0x00, 0)
 }
 
-func ioctl(fd, name, data uintptr) {
-	_, _, _ = syscall.Syscall(syscall.SYS_IOCTL, fd, name, data)
+func (l *LinuxDriver) Close() {
+	ioctl(l.file.Fd(), uiDevDestroy, 0)
+	l.file.Close()
 }
@@ -4,6 +4,7 @@ package drivers
 
 import (
 	"syscall"
+	"unicode/utf16"
 	"unsafe"
 )
 
@@ -30,12 +31,37 @@ type input struct {
 	mi mouseInput
 }
 
-func InitDriver() MouseDriver {
+// KEYBDINPUT represents the Windows C-struct. The trailing padding makes it
+// as large as MOUSEINPUT, which is what sizes the INPUT union.
+type keybdInput struct {
+	wVk         uint16
+	wScan       uint16
+	dwFlags     uint32
+	time        uint32
+	dwExtraInfo uintptr
+	_           [8]byte
+}
+
+// keyInput is INPUT with the keyboard member of the union selected.
+type keyInput struct {
+	inputType uint32
+	_         uint32
+	ki        keybdInput
+}
+
+// WheelNotch is the Scroll delta of one wheel click, WHEEL_DELTA.
+const WheelNotch = 120
+
+func InitDriver(id Identity) (Driver, error) {
 	lib := syscall.NewLazyDLL("user32.dll")
+	proc := lib.NewProc("SendInput")
+	if err := proc.Find(); err != nil {
+		return nil, err
+	}
 	return &WinDriver{
 		user32: lib,
-		proc:   lib.NewProc("SendInput"),
-	}
+		proc:   proc,
+	}, nil
 }
 
 func (w *WinDriver) Send(f uint32, x, y, d int32) {
@@ -60,21 +86,68 @@ func (w *WinDriver) Send(f uint32, x, y, d int32) {
 
 func (w *WinDriver) Move(dx, dy int32) { w.Send(0x0001, dx, dy, 0) } // MOUSEEVENTF_MOVE
 func (w *WinDriver) Scroll(d int32)    { w.Send(0x0800, 0, 0, d) }   // MOUSEEVENTF_WHEEL
+func (w *WinDriver) HScroll(d int32)   { w.Send(0x1000, 0, 0, d) }   // MOUSEEVENTF_HWHEEL
 func (w *WinDriver) Button(b string, down bool) {
 	var f uint32
-	if b == "left" {
+	switch b {
+	case "right":
 		if down {
-			f = 0x0002
+			f = 0x0008
 		} else {
-			f = 0x0004
+			f = 0x0010
 		}
-	} else {
+	case "middle":
 		if down {
-			f = 0x0008
+			f = 0x0020
 		} else {
-			f = 0x0010
+			f = 0x0040
+		}
+	default:
+		if down {
+			f = 0x0002
+		} else {
+			f = 0x0004
 		}
 	}
 	w.Send(f, 0, 0, 0)
 }
+func (w *WinDriver) Key(k Key, down bool) {
+	var ki keybdInput
+	ki.wVk = k.vk
+	if k.extended {
+		ki.dwFlags |= 0x0001 // KEYEVENTF_EXTENDEDKEY
+	}
+	if !down {
+		ki.dwFlags |= 0x0002 // KEYEVENTF_KEYUP
+	}
+	w.sendKey(ki)
+}
+
+// Type sends each character as a Unicode key press, so any text comes out
+// whatever the keyboard layout. Newline and tab are sent as Enter and Tab,
+// which is what applications expect.
+func (w *WinDriver) Type(text string) int {
+	for _, r := range text {
+		if r == '\n' || r == '\t' {
+			c, _ := RuneChord(r)
+			c.Press(w)
+			continue
+		}
+		for _, unit := range utf16.Encode([]rune{r}) {
+			w.sendKey(keybdInput{wScan: unit, dwFlags: 0x0004})          // KEYEVENTF_UNICODE
+			w.sendKey(keybdInput{wScan: unit, dwFlags: 0x0004 | 0x0002}) // ... | KEYEVENTF_KEYUP
+		}
+	}
+	return 0
+}
+
+func (w *WinDriver) sendKey(ki keybdInput) {
+	i := keyInput{inputType: 1, ki: ki} // INPUT_KEYBOARD
+	w.proc.Call(
+		uintptr(1),
+		uintptr(unsafe.Pointer(&i)),
+		uintptr(unsafe.Sizeof(i)),
+	)
+}
+
 func (w *WinDriver) Close() {}
@@ -0,0 +1,16 @@
+package drivers
+
+// Bus types for Identity, from linux/input.h.
+const (
+	BusUSB       = 0x03
+	BusBluetooth = 0x05
+	BusVirtual   = 0x06
+)
+
+// Identity is how the virtual device presents itself to the OS, which is
+// what udev rules, hwdb entries and libinput quirks match on. Only the
+// Linux driver uses it.
+type Identity struct {
+	Name                          string
+	Bus, Vendor, Product, Version uint16
+}
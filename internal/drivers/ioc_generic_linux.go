@@ -0,0 +1,11 @@
+//go:build linux && !(mips || mipsle || mips64 || mips64le || ppc64 || ppc64le)
+
+package drivers
+
+// The asm-generic _IOC layout: 2 direction bits above 14 size bits.
+const (
+	iocNone      = 0
+	iocWrite     = 1
+	iocSizeShift = 16
+	iocDirShift  = 30
+)
@@ -0,0 +1,12 @@
+//go:build linux && (mips || mipsle || mips64 || mips64le || ppc64 || ppc64le)
+
+package drivers
+
+// MIPS and PowerPC have 3 direction bits above 13 size bits, and give
+// _IOC_NONE a bit of its own.
+const (
+	iocNone      = 1
+	iocWrite     = 4
+	iocSizeShift = 16
+	iocDirShift  = 29
+)
@@ -0,0 +1,234 @@
+package drivers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Key identifies a keyboard key independently of the host OS. Each entry
+// carries the Linux input event code and the Windows virtual-key code so the
+// platform drivers can share one table.
+type Key struct {
+	name     string
+	linux    uint16 // KEY_* from linux/input-event-codes.h
+	vk       uint16 // VK_* from winuser.h
+	extended bool   // needs KEYEVENTF_EXTENDEDKEY on Windows
+}
+
+func (k Key) String() string { return k.name }
+
+var keyTable = []Key{
+	{"esc", 1, 0x1B, false},
+	{"1", 2, 0x31, false},
+	{"2", 3, 0x32, false},
+	{"3", 4, 0x33, false},
+	{"4", 5, 0x34, false},
+	{"5", 6, 0x35, false},
+	{"6", 7, 0x36, false},
+	{"7", 8, 0x37, false},
+	{"8", 9, 0x38, false},
+	{"9", 10, 0x39, false},
+	{"0", 11, 0x30, false},
+	{"minus", 12, 0xBD, false},
+	{"equal", 13, 0xBB, false},
+	{"backspace", 14, 0x08, false},
+	{"tab", 15, 0x09, false},
+	{"q", 16, 0x51, false},
+	{"w", 17, 0x57, false},
+	{"e", 18, 0x45, false},
+	{"r", 19, 0x52, false},
+	{"t", 20, 0x54, false},
+	{"y", 21, 0x59, false},
+	{"u", 22, 0x55, false},
+	{"i", 23, 0x49, false},
+	{"o", 24, 0x4F, false},
+	{"p", 25, 0x50, false},
+	{"leftbracket", 26, 0xDB, false},
+	{"rightbracket", 27, 0xDD, false},
+	{"enter", 28, 0x0D, false},
+	{"ctrl", 29, 0xA2, false},
+	{"a", 30, 0x41, false},
+	{"s", 31, 0x53, false},
+	{"d", 32, 0x44, false},
+	{"f", 33, 0x46, false},
+	{"g", 34, 0x47, false},
+	{"h", 35, 0x48, false},
+	{"j", 36, 0x4A, false},
+	{"k", 37, 0x4B, false},
+	{"l", 38, 0x4C, false},
+	{"semicolon", 39, 0xBA, false},
+	{"apostrophe", 40, 0xDE, false},
+	{"grave", 41, 0xC0, false},
+	{"shift", 42, 0xA0, false},
+	{"backslash", 43, 0xDC, false},
+	{"z", 44, 0x5A, false},
+	{"x", 45, 0x58, false},
+	{"c", 46, 0x43, false},
+	{"v", 47, 0x56, false},
+	{"b", 48, 0x42, false},
+	{"n", 49, 0x4E, false},
+	{"m", 50, 0x4D, false},
+	{"comma", 51, 0xBC, false},
+	{"period", 52, 0xBE, false},
+	{"slash", 53, 0xBF, false},
+	{"rshift", 54, 0xA1, false},
+	{"alt", 56, 0xA4, false},
+	{"space", 57, 0x20, false},
+	{"capslock", 58, 0x14, false},
+	{"f1", 59, 0x70, false},
+	{"f2", 60, 0x71, false},
+	{"f3", 61, 0x72, false},
+	{"f4", 62, 0x73, false},
+	{"f5", 63, 0x74, false},
+	{"f6", 64, 0x75, false},
+	{"f7", 65, 0x76, false},
+	{"f8", 66, 0x77, false},
+	{"f9", 67, 0x78, false},
+	{"f10", 68, 0x79, false},
+	{"f11", 87, 0x7A, false},
+	{"f12", 88, 0x7B, false},
+	{"rctrl", 97, 0xA3, true},
+	{"print", 99, 0x2C, true},
+	{"ralt", 100, 0xA5, true},
+	{"home", 102, 0x24, true},
+	{"up", 103, 0x26, true},
+	{"pageup", 104, 0x21, true},
+	{"left", 105, 0x25, true},
+	{"right", 106, 0x27, true},
+	{"end", 107, 0x23, true},
+	{"down", 108, 0x28, true},
+	{"pagedown", 109, 0x22, true},
+	{"insert", 110, 0x2D, true},
+	{"delete", 111, 0x2E, true},
+	{"mute", 113, 0xAD, true},
+	{"volumedown", 114, 0xAE, true},
+	{"volumeup", 115, 0xAF, true},
+	{"super", 125, 0x5B, true},
+	{"rsuper", 126, 0x5C, true},
+	{"menu", 127, 0x5D, true},
+	{"browserback", 158, 0xA6, true},
+	{"browserforward", 159, 0xA7, true},
+	{"nexttrack", 163, 0xB0, true},
+	{"playpause", 164, 0xB3, true},
+	{"prevtrack", 165, 0xB1, true},
+	{"stopmedia", 166, 0xB2, true},
+}
+
+var keyAliases = map[string]string{
+	"control": "ctrl",
+	"win":     "super",
+	"meta":    "super",
+	"cmd":     "super",
+	"option":  "alt",
+	"escape":  "esc",
+	"return":  "enter",
+	"del":     "delete",
+	"ins":     "insert",
+	"pgup":    "pageup",
+	"pgdn":    "pagedown",
+	"dot":     "period",
+	"[":       "leftbracket",
+	"]":       "rightbracket",
+	";":       "semicolon",
+	"'":       "apostrophe",
+	"`":       "grave",
+	"\\":      "backslash",
+	",":       "comma",
+	".":       "period",
+	"/":       "slash",
+	"-":       "minus",
+	"=":       "equal",
+}
+
+var keysByName = func() map[string]Key {
+	m := make(map[string]Key, len(keyTable))
+	for _, k := range keyTable {
+		m[k.name] = k
+	}
+	return m
+}()
+
+// LookupKey resolves a key name (case-insensitive, aliases allowed).
+func LookupKey(name string) (Key, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := keyAliases[name]; ok {
+		name = alias
+	}
+	k, ok := keysByName[name]
+	return k, ok
+}
+
+// Chord is a set of keys pressed together, modifiers first.
+type Chord []Key
+
+// ParseChord parses "+"-separated key names such as "ctrl+shift+t".
+func ParseChord(s string) (Chord, error) {
+	var c Chord
+	for _, part := range strings.Split(s, "+") {
+		k, ok := LookupKey(part)
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", strings.TrimSpace(part))
+		}
+		c = append(c, k)
+	}
+	return c, nil
+}
+
+// Press holds every key in order and releases them in reverse, so the
+// modifiers wrap the final key.
+func (c Chord) Press(kb KeyboardDriver) {
+	for _, k := range c {
+		kb.Key(k, true)
+	}
+	for i := len(c) - 1; i >= 0; i-- {
+		kb.Key(c[i], false)
+	}
+}
+
+func (c Chord) String() string {
+	names := make([]string, len(c))
+	for i, k := range c {
+		names[i] = k.name
+	}
+	return strings.Join(names, "+")
+}
+
+// shiftedRunes are the characters a US layout types with Shift, mapped to
+// the key that carries them.
+var shiftedRunes = map[rune]string{
+	'!': "1", '@': "2", '#': "3", '$': "4", '%': "5",
+	'^': "6", '&': "7", '*': "8", '(': "9", ')': "0",
+	'_': "minus", '+': "equal", '{': "leftbracket", '}': "rightbracket",
+	'|': "backslash", ':': "semicolon", '"': "apostrophe", '~': "grave",
+	'<': "comma", '>': "period", '?': "slash",
+}
+
+// RuneChord returns the keys that type r on a US layout. Only printable
+// ASCII, newline and tab can be typed this way.
+func RuneChord(r rune) (Chord, bool) {
+	var name string
+	shift := false
+	switch {
+	case r == '\n':
+		name = "enter"
+	case r == '\t':
+		name = "tab"
+	case r == ' ':
+		name = "space"
+	case r >= 'A' && r <= 'Z':
+		name, shift = string(r-'A'+'a'), true
+	case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		name = string(r)
+	case shiftedRunes[r] != "":
+		name, shift = shiftedRunes[r], true
+	case r < 0x80 && keyAliases[string(r)] != "":
+		name = keyAliases[string(r)]
+	default:
+		return nil, false
+	}
+	k := keysByName[name]
+	if shift {
+		return Chord{keysByName["shift"], k}, true
+	}
+	return Chord{k}, true
+}
@@ -0,0 +1,84 @@
+package drivers
+
+import "testing"
+
+func TestParseChord(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string // Chord.String(), "" when an error is expected
+		wantErr string
+	}{
+		{in: "a", want: "a"},
+		{in: "ctrl+shift+t", want: "ctrl+shift+t"},
+		{in: "super+ctrl+left", want: "super+ctrl+left"},
+
+		// Case and surrounding space do not matter.
+		{in: "CTRL+Shift+T", want: "ctrl+shift+t"},
+		{in: " alt + tab ", want: "alt+tab"},
+
+		// Aliases resolve to the canonical name.
+		{in: "control+c", want: "ctrl+c"},
+		{in: "win+d", want: "super+d"},
+		{in: "Cmd+Q", want: "super+q"},
+		{in: "escape", want: "esc"},
+		{in: "ctrl+pgdn", want: "ctrl+pagedown"},
+		{in: "ctrl+-", want: "ctrl+minus"},
+		{in: "ctrl+=", want: "ctrl+equal"},
+
+		// Bad names say which part was wrong.
+		{in: "ctrl+shfit+t", wantErr: `unknown key "shfit"`},
+		{in: "hyper", wantErr: `unknown key "hyper"`},
+		{in: "", wantErr: `unknown key ""`},
+		{in: "ctrl+", wantErr: `unknown key ""`},
+		{in: "ctrl++", wantErr: `unknown key ""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			c, err := ParseChord(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ParseChord(%q) = %v, %v; want error %s", tt.in, c, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseChord(%q): %v", tt.in, err)
+			}
+			if got := c.String(); got != tt.want {
+				t.Errorf("ParseChord(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingKeyboard logs key transitions for Chord.Press.
+type recordingKeyboard []string
+
+func (r *recordingKeyboard) Key(k Key, down bool) {
+	state := "up"
+	if down {
+		state = "down"
+	}
+	*r = append(*r, k.String()+" "+state)
+}
+
+func (r *recordingKeyboard) Type(string) int { return 0 }
+func (r *recordingKeyboard) Close()          {}
+
+func TestChordPressWrapsModifiers(t *testing.T) {
+	c, err := ParseChord("ctrl+shift+t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kb recordingKeyboard
+	c.Press(&kb)
+	want := []string{"ctrl down", "shift down", "t down", "t up", "shift up", "ctrl up"}
+	if len(kb) != len(want) {
+		t.Fatalf("Press sent %q, want %q", kb, want)
+	}
+	for i := range want {
+		if kb[i] != want[i] {
+			t.Fatalf("Press sent %q, want %q", kb, want)
+		}
+	}
+}
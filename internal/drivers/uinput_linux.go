@@ -0,0 +1,84 @@
+//go:build linux
+
+package drivers
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// The uinput ABI, from linux/input.h and linux/uinput.h. The structs are
+// declared with Go types whose size and alignment follow the C ones on
+// every architecture, and are written in native byte order, so the same
+// code is right on 32- and 64-bit, little- and big-endian hosts.
+
+// inputEvent is struct input_event. Its time is a struct timeval of two C
+// longs, which are word sized; the kernel fills it in for us.
+type inputEvent struct {
+	Sec, Usec uintptr
+	Type      uint16
+	Code      uint16
+	Value     int32
+}
+
+// inputID is struct input_id.
+type inputID struct {
+	Bustype, Vendor, Product, Version uint16
+}
+
+// uinputSetup is struct uinput_setup.
+type uinputSetup struct {
+	ID           inputID
+	Name         [80]byte // UINPUT_MAX_NAME_SIZE
+	FFEffectsMax uint32
+}
+
+// Sizes of the structs in the kernel ABI. A mismatch fails to compile:
+// the index is out of range, or the subtraction overflows.
+const (
+	wordSize         = unsafe.Sizeof(uintptr(0))
+	inputEventSize   = 2*wordSize + 8
+	uinputSetupSize  = 92
+	uinputIoctlIntSz = 4 // the ioctls below that take an int
+)
+
+var (
+	_ = [1]struct{}{}[unsafe.Sizeof(inputEvent{})-inputEventSize]
+	_ = [1]struct{}{}[unsafe.Sizeof(uinputSetup{})-uinputSetupSize]
+)
+
+// ioc builds an ioctl request number like the kernel's _IOC macro, using
+// the field layout of the host architecture (see ioc_*_linux.go).
+func ioc(dir, typ, nr, size uintptr) uintptr {
+	return dir<<iocDirShift | size<<iocSizeShift | typ<<8 | nr
+}
+
+// ioNone and ioWrite are _IO and _IOW.
+func ioNone(typ, nr uintptr) uintptr        { return ioc(iocNone, typ, nr, 0) }
+func ioWrite(typ, nr, size uintptr) uintptr { return ioc(iocWrite, typ, nr, size) }
+
+const uinputIoctlBase = 'U'
+
+// uinput requests.
+var (
+	uiDevCreate  = ioNone(uinputIoctlBase, 1)
+	uiDevDestroy = ioNone(uinputIoctlBase, 2)
+	uiDevSetup   = ioWrite(uinputIoctlBase, 3, uinputSetupSize)
+	uiSetEvBit   = ioWrite(uinputIoctlBase, 100, uinputIoctlIntSz)
+	uiSetKeyBit  = ioWrite(uinputIoctlBase, 101, uinputIoctlIntSz)
+	uiSetRelBit  = ioWrite(uinputIoctlBase, 102, uinputIoctlIntSz)
+)
+
+func ioctl(fd, name, data uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, name, data); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func ioctlPtr(fd, name uintptr, data unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, name, uintptr(data)); errno != 0 {
+		return errno
+	}
+	return nil
+}
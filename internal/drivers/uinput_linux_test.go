@@ -0,0 +1,79 @@
+//go:build linux
+
+package drivers
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+// wantInputEventSize is sizeof(struct input_event) from the kernel headers
+// of each architecture: a struct timeval of two longs and 8 bytes more.
+var wantInputEventSize = map[string]uintptr{
+	"386": 16, "arm": 16, "mips": 16, "mipsle": 16,
+	"amd64": 24, "arm64": 24, "loong64": 24, "mips64": 24, "mips64le": 24,
+	"ppc64": 24, "ppc64le": 24, "riscv64": 24, "s390x": 24,
+}
+
+func TestUinputStructSizes(t *testing.T) {
+	want, ok := wantInputEventSize[runtime.GOARCH]
+	if !ok {
+		t.Fatalf("no expected input_event size for GOARCH %s", runtime.GOARCH)
+	}
+	if got := unsafe.Sizeof(inputEvent{}); got != want {
+		t.Errorf("sizeof(input_event) = %d on %s, want %d", got, runtime.GOARCH, want)
+	}
+	if got := unsafe.Sizeof(inputID{}); got != 8 {
+		t.Errorf("sizeof(input_id) = %d, want 8", got)
+	}
+	if got := unsafe.Sizeof(uinputSetup{}); got != 92 {
+		t.Errorf("sizeof(uinput_setup) = %d, want 92", got)
+	}
+	if got := unsafe.Offsetof(uinputSetup{}.FFEffectsMax); got != 88 {
+		t.Errorf("offsetof(uinput_setup.ff_effects_max) = %d, want 88", got)
+	}
+	if got, want := unsafe.Offsetof(inputEvent{}.Type), 2*unsafe.Sizeof(uintptr(0)); got != want {
+		t.Errorf("offsetof(input_event.type) = %d, want %d", got, want)
+	}
+}
+
+func TestUinputIoctls(t *testing.T) {
+	// From linux/uinput.h as the kernel builds them. MIPS and PowerPC put
+	// _IOC_WRITE at bit 31 rather than 30 and give _IO requests bit 29.
+	generic := map[string]uintptr{
+		"UI_DEV_CREATE":  0x5501,
+		"UI_DEV_DESTROY": 0x5502,
+		"UI_DEV_SETUP":   0x405c5503,
+		"UI_SET_EVBIT":   0x40045564,
+		"UI_SET_KEYBIT":  0x40045565,
+		"UI_SET_RELBIT":  0x40045566,
+	}
+	mipsPPC := map[string]uintptr{
+		"UI_DEV_CREATE":  0x20005501,
+		"UI_DEV_DESTROY": 0x20005502,
+		"UI_DEV_SETUP":   0x805c5503,
+		"UI_SET_EVBIT":   0x80045564,
+		"UI_SET_KEYBIT":  0x80045565,
+		"UI_SET_RELBIT":  0x80045566,
+	}
+	want := generic
+	switch runtime.GOARCH {
+	case "mips", "mipsle", "mips64", "mips64le", "ppc64", "ppc64le":
+		want = mipsPPC
+	}
+
+	got := map[string]uintptr{
+		"UI_DEV_CREATE":  uiDevCreate,
+		"UI_DEV_DESTROY": uiDevDestroy,
+		"UI_DEV_SETUP":   uiDevSetup,
+		"UI_SET_EVBIT":   uiSetEvBit,
+		"UI_SET_KEYBIT":  uiSetKeyBit,
+		"UI_SET_RELBIT":  uiSetRelBit,
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s = %#x on %s, want %#x", name, got[name], runtime.GOARCH, w)
+		}
+	}
+}
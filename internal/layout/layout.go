@@ -0,0 +1,36 @@
+// Package layout describes regions of the touch surface in pad space. It is
+// shared by the host, which acts on them, and the Android app, which draws
+// them so users can see where they are. The host sends the layout to the
+// app over the proto link.
+//
+// Pad space is the phone as the user sees it in landscape: 0..1 on both
+// axes, origin at the top-left.
+package layout
+
+// Zone is a rectangle that acts as a mouse button while a finger rests in it.
+type Zone struct {
+	Button string  `json:"button"` // "left", "right" or "middle"
+	Left   float64 `json:"left"`
+	Top    float64 `json:"top"`
+	Right  float64 `json:"right"`
+	Bottom float64 `json:"bottom"`
+}
+
+// Contains reports whether the pad-space point lies inside the zone.
+func (z Zone) Contains(x, y float64) bool {
+	return x >= z.Left && x < z.Right && y >= z.Top && y < z.Bottom
+}
+
+// Layout is everything the app needs to draw on its surface.
+type Layout struct {
+	Zones []Zone `json:"zones"`
+}
+
+// PauseButton is the corner of the surface where the app draws its
+// pause/resume control. The host ignores touches that land there.
+var PauseButton = Zone{Left: 0.92, Top: 0, Right: 1, Bottom: 0.14}
+
+// KeyboardButton, next to PauseButton, is where the app draws the control
+// that opens the phone's keyboard for typing on the PC. The host ignores
+// touches that land there too.
+var KeyboardButton = Zone{Left: 0.84, Top: 0, Right: 0.92, Bottom: 0.14}
@@ -0,0 +1,698 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Android binary XML (AXML), the compiled form of AndroidManifest.xml in an
+// APK. A document is a RES_XML_TYPE chunk holding a string pool, a resource
+// map and a flat sequence of namespace, element and text nodes. Everything
+// that names something, from element and attribute names to string values,
+// is an index into the string pool. The first strings are attribute names,
+// and the resource map gives each of them its android.R.attr ID, which is
+// what Android actually matches attributes by.
+//
+// The layouts are those of frameworks/base/libs/androidfw/ResourceTypes.h.
+
+// Chunk types.
+const (
+	resStringPoolType   = 0x0001
+	resXMLType          = 0x0003
+	resXMLStartNSType   = 0x0100
+	resXMLEndNSType     = 0x0101
+	resXMLStartElemType = 0x0102
+	resXMLEndElemType   = 0x0103
+	resXMLCDataType     = 0x0104
+	resXMLResMapType    = 0x0180
+)
+
+// Res_value data types used in manifests.
+const (
+	typeReference = 0x01
+	typeString    = 0x03
+	typeIntDec    = 0x10
+	typeIntBool   = 0x12
+)
+
+const (
+	noIndex        = 0xFFFFFFFF // no string, e.g. an attribute without a namespace
+	stringPoolUTF8 = 1 << 8
+	androidNS      = "http://schemas.android.com/apk/res/android"
+)
+
+// androidAttrs are the android.R.attr IDs of the manifest attributes the
+// patcher can set.
+var androidAttrs = map[string]uint32{
+	"theme":             0x01010000,
+	"label":             0x01010001,
+	"icon":              0x01010002,
+	"name":              0x01010003,
+	"debuggable":        0x0101000f,
+	"exported":          0x01010010,
+	"screenOrientation": 0x0101001e,
+	"configChanges":     0x0101001f,
+	"minSdkVersion":     0x0101020c,
+	"versionCode":       0x0101021b,
+	"versionName":       0x0101021c,
+	"targetSdkVersion":  0x01010270,
+	"maxSdkVersion":     0x01010271,
+}
+
+// axmlAttr is one attribute of an element. Raw is the original string for
+// string values and noIndex otherwise.
+type axmlAttr struct {
+	NS, Name, Raw uint32
+	Type          uint8
+	Data          uint32
+}
+
+// axmlNode is one node of the document. Which fields are used depends on
+// Type; chunks of unknown type keep their bytes in Raw.
+type axmlNode struct {
+	Type          uint16
+	Line, Comment uint32
+
+	// Namespaces
+	Prefix, URI uint32
+
+	// Elements; an end element only has NS and Name.
+	NS, Name                        uint32
+	IDIndex, ClassIndex, StyleIndex uint16
+	Attrs                           []axmlAttr
+
+	// Text: the string and its typed value.
+	CData      uint32
+	CDataType  uint8
+	CDataValue uint32
+
+	Raw []byte
+}
+
+// axmlDoc is a decoded AXML document. Indexes below len(ResIDs) in Strings
+// are attribute names with those resource IDs.
+type axmlDoc struct {
+	Strings []string
+	UTF8    bool
+	ResIDs  []uint32
+	Nodes   []axmlNode
+}
+
+var errAXML = errors.New("malformed binary XML")
+
+// parseAXML decodes a binary XML document.
+func parseAXML(data []byte) (*axmlDoc, error) {
+	typ, hdr, size, err := chunkHeader(data)
+	if err != nil || typ != resXMLType || int(size) > len(data) {
+		return nil, fmt.Errorf("%w: not an XML chunk", errAXML)
+	}
+	d := &axmlDoc{}
+	for off := int(hdr); off < int(size); {
+		typ, hdr, csize, err := chunkHeader(data[off:size])
+		if err != nil || csize < uint32(hdr) || off+int(csize) > int(size) {
+			return nil, fmt.Errorf("%w: bad chunk at %#x", errAXML, off)
+		}
+		chunk := data[off : off+int(csize)]
+		switch typ {
+		case resStringPoolType:
+			err = d.parseStrings(chunk, hdr)
+		case resXMLResMapType:
+			for i := int(hdr); i+4 <= len(chunk); i += 4 {
+				d.ResIDs = append(d.ResIDs, le.Uint32(chunk[i:]))
+			}
+		case resXMLStartNSType, resXMLEndNSType, resXMLStartElemType, resXMLEndElemType, resXMLCDataType:
+			var n axmlNode
+			n, err = parseNode(typ, chunk, hdr)
+			d.Nodes = append(d.Nodes, n)
+		default:
+			d.Nodes = append(d.Nodes, axmlNode{Type: typ, Raw: append([]byte(nil), chunk...)})
+		}
+		if err != nil {
+			return nil, err
+		}
+		off += int(csize)
+	}
+	return d, nil
+}
+
+var le = binary.LittleEndian
+
+func chunkHeader(b []byte) (typ, hdr uint16, size uint32, err error) {
+	if len(b) < 8 {
+		return 0, 0, 0, errAXML
+	}
+	typ, hdr, size = le.Uint16(b), le.Uint16(b[2:]), le.Uint32(b[4:])
+	if hdr < 8 || uint32(hdr) > size {
+		return 0, 0, 0, errAXML
+	}
+	return typ, hdr, size, nil
+}
+
+func (d *axmlDoc) parseStrings(c []byte, hdr uint16) error {
+	if hdr < 28 {
+		return fmt.Errorf("%w: short string pool header", errAXML)
+	}
+	count, styles := le.Uint32(c[8:]), le.Uint32(c[12:])
+	flags, start := le.Uint32(c[16:]), le.Uint32(c[20:])
+	if styles > 0 {
+		// Style spans refer to strings by index, which adding attribute
+		// names would break. Manifests never have any.
+		return fmt.Errorf("%w: styled strings are not supported", errAXML)
+	}
+	d.UTF8 = flags&stringPoolUTF8 != 0
+	offsets := int(hdr)
+	if offsets+4*int(count) > len(c) || int(start) > len(c) {
+		return fmt.Errorf("%w: string pool out of range", errAXML)
+	}
+	for i := 0; i < int(count); i++ {
+		pos := int(start) + int(le.Uint32(c[offsets+4*i:]))
+		s, err := decodeString(c, pos, d.UTF8)
+		if err != nil {
+			return err
+		}
+		d.Strings = append(d.Strings, s)
+	}
+	return nil
+}
+
+func decodeString(c []byte, pos int, utf8 bool) (string, error) {
+	bad := fmt.Errorf("%w: string at %#x out of range", errAXML, pos)
+	if utf8 {
+		// UTF-16 length, then UTF-8 length, each one or two bytes.
+		n := 0
+		for i := 0; i < 2; i++ {
+			if pos >= len(c) {
+				return "", bad
+			}
+			n = int(c[pos])
+			pos++
+			if n&0x80 != 0 {
+				if pos >= len(c) {
+					return "", bad
+				}
+				n = (n&0x7F)<<8 | int(c[pos])
+				pos++
+			}
+		}
+		if pos+n > len(c) {
+			return "", bad
+		}
+		return string(c[pos : pos+n]), nil
+	}
+	if pos+2 > len(c) {
+		return "", bad
+	}
+	n := int(le.Uint16(c[pos:]))
+	pos += 2
+	if n&0x8000 != 0 {
+		if pos+2 > len(c) {
+			return "", bad
+		}
+		n = (n&0x7FFF)<<16 | int(le.Uint16(c[pos:]))
+		pos += 2
+	}
+	if pos+2*n > len(c) {
+		return "", bad
+	}
+	units := make([]uint16, n)
+	for i := range units {
+		units[i] = le.Uint16(c[pos+2*i:])
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+func parseNode(typ uint16, c []byte, hdr uint16) (axmlNode, error) {
+	n := axmlNode{Type: typ}
+	if hdr < 16 {
+		return n, fmt.Errorf("%w: short node header", errAXML)
+	}
+	n.Line, n.Comment = le.Uint32(c[8:]), le.Uint32(c[12:])
+	ext := c[hdr:]
+	short := fmt.Errorf("%w: short node", errAXML)
+	switch typ {
+	case resXMLStartNSType, resXMLEndNSType:
+		if len(ext) < 8 {
+			return n, short
+		}
+		n.Prefix, n.URI = le.Uint32(ext), le.Uint32(ext[4:])
+	case resXMLEndElemType:
+		if len(ext) < 8 {
+			return n, short
+		}
+		n.NS, n.Name = le.Uint32(ext), le.Uint32(ext[4:])
+	case resXMLCDataType:
+		if len(ext) < 12 {
+			return n, short
+		}
+		n.CData, n.CDataType, n.CDataValue = le.Uint32(ext), ext[7], le.Uint32(ext[8:])
+	case resXMLStartElemType:
+		if len(ext) < 20 {
+			return n, short
+		}
+		n.NS, n.Name = le.Uint32(ext), le.Uint32(ext[4:])
+		start, stride, count := int(le.Uint16(ext[8:])), int(le.Uint16(ext[10:])), int(le.Uint16(ext[12:]))
+		n.IDIndex, n.ClassIndex, n.StyleIndex = le.Uint16(ext[14:]), le.Uint16(ext[16:]), le.Uint16(ext[18:])
+		if stride < 20 || start+stride*count > len(ext) {
+			return n, fmt.Errorf("%w: attributes out of range", errAXML)
+		}
+		for i := 0; i < count; i++ {
+			a := ext[start+stride*i:]
+			n.Attrs = append(n.Attrs, axmlAttr{
+				NS:   le.Uint32(a),
+				Name: le.Uint32(a[4:]),
+				Raw:  le.Uint32(a[8:]),
+				Type: a[15],
+				Data: le.Uint32(a[16:]),
+			})
+		}
+	}
+	return n, nil
+}
+
+// Bytes encodes the document.
+func (d *axmlDoc) Bytes() []byte {
+	var body []byte
+	body = append(body, d.stringPool()...)
+	if len(d.ResIDs) > 0 {
+		body = appendChunkHeader(body, resXMLResMapType, 8, uint32(8+4*len(d.ResIDs)))
+		for _, id := range d.ResIDs {
+			body = le.AppendUint32(body, id)
+		}
+	}
+	for _, n := range d.Nodes {
+		body = append(body, n.bytes()...)
+	}
+	return append(appendChunkHeader(nil, resXMLType, 8, uint32(8+len(body))), body...)
+}
+
+func appendChunkHeader(b []byte, typ, hdr uint16, size uint32) []byte {
+	b = le.AppendUint16(b, typ)
+	b = le.AppendUint16(b, hdr)
+	return le.AppendUint32(b, size)
+}
+
+func (d *axmlDoc) stringPool() []byte {
+	var offsets, data []byte
+	for _, s := range d.Strings {
+		offsets = le.AppendUint32(offsets, uint32(len(data)))
+		data = appendString(data, s, d.UTF8)
+	}
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+
+	const hdr = 28
+	start := hdr + len(offsets)
+	var flags uint32 // never sorted, as strings are added at the end
+	if d.UTF8 {
+		flags |= stringPoolUTF8
+	}
+
+	b := appendChunkHeader(nil, resStringPoolType, hdr, uint32(start+len(data)))
+	b = le.AppendUint32(b, uint32(len(d.Strings)))
+	b = le.AppendUint32(b, 0) // styleCount
+	b = le.AppendUint32(b, flags)
+	b = le.AppendUint32(b, uint32(start))
+	b = le.AppendUint32(b, 0) // stylesStart
+	b = append(b, offsets...)
+	return append(b, data...)
+}
+
+func appendString(b []byte, s string, utf8 bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if utf8 {
+		for _, n := range []int{len(units), len(s)} {
+			if n > 0x7F {
+				b = append(b, byte(n>>8)|0x80)
+			}
+			b = append(b, byte(n))
+		}
+		b = append(b, s...)
+		return append(b, 0)
+	}
+	if len(units) > 0x7FFF {
+		b = le.AppendUint16(b, uint16(len(units)>>16)|0x8000)
+	}
+	b = le.AppendUint16(b, uint16(len(units)))
+	for _, u := range units {
+		b = le.AppendUint16(b, u)
+	}
+	return le.AppendUint16(b, 0)
+}
+
+func (n axmlNode) bytes() []byte {
+	var ext []byte
+	switch n.Type {
+	case resXMLStartNSType, resXMLEndNSType:
+		ext = le.AppendUint32(ext, n.Prefix)
+		ext = le.AppendUint32(ext, n.URI)
+	case resXMLEndElemType:
+		ext = le.AppendUint32(ext, n.NS)
+		ext = le.AppendUint32(ext, n.Name)
+	case resXMLCDataType:
+		ext = le.AppendUint32(ext, n.CData)
+		ext = appendValue(ext, n.CDataType, n.CDataValue)
+	case resXMLStartElemType:
+		ext = le.AppendUint32(ext, n.NS)
+		ext = le.AppendUint32(ext, n.Name)
+		ext = le.AppendUint16(ext, 20) // attributeStart
+		ext = le.AppendUint16(ext, 20) // attributeSize
+		ext = le.AppendUint16(ext, uint16(len(n.Attrs)))
+		ext = le.AppendUint16(ext, n.IDIndex)
+		ext = le.AppendUint16(ext, n.ClassIndex)
+		ext = le.AppendUint16(ext, n.StyleIndex)
+		for _, a := range n.Attrs {
+			ext = le.AppendUint32(ext, a.NS)
+			ext = le.AppendUint32(ext, a.Name)
+			ext = le.AppendUint32(ext, a.Raw)
+			ext = appendValue(ext, a.Type, a.Data)
+		}
+	default:
+		return n.Raw
+	}
+	b := appendChunkHeader(nil, n.Type, 16, uint32(16+len(ext)))
+	b = le.AppendUint32(b, n.Line)
+	b = le.AppendUint32(b, n.Comment)
+	return append(b, ext...)
+}
+
+// appendValue appends a Res_value.
+func appendValue(b []byte, typ uint8, data uint32) []byte {
+	b = le.AppendUint16(b, 8)
+	b = append(b, 0, typ)
+	return le.AppendUint32(b, data)
+}
+
+// String returns the XML text of the document, for checking a patch.
+func (d *axmlDoc) String() string {
+	var sb strings.Builder
+	depth := 0
+	for _, n := range d.Nodes {
+		switch n.Type {
+		case resXMLStartElemType:
+			fmt.Fprintf(&sb, "%s<%s", strings.Repeat("  ", depth), d.str(n.Name))
+			for _, a := range n.Attrs {
+				name := d.str(a.Name)
+				if a.NS != noIndex {
+					name = d.prefix(a.NS) + ":" + name
+				}
+				fmt.Fprintf(&sb, " %s=%q", name, d.value(a))
+			}
+			sb.WriteString(">\n")
+			depth++
+		case resXMLEndElemType:
+			depth--
+			fmt.Fprintf(&sb, "%s</%s>\n", strings.Repeat("  ", depth), d.str(n.Name))
+		}
+	}
+	return sb.String()
+}
+
+func (d *axmlDoc) str(i uint32) string {
+	if int(i) < len(d.Strings) {
+		return d.Strings[i]
+	}
+	return ""
+}
+
+func (d *axmlDoc) prefix(uri uint32) string {
+	for _, n := range d.Nodes {
+		if n.Type == resXMLStartNSType && n.URI == uri {
+			return d.str(n.Prefix)
+		}
+	}
+	return d.str(uri)
+}
+
+func (d *axmlDoc) value(a axmlAttr) string {
+	switch a.Type {
+	case typeString:
+		return d.str(a.Data)
+	case typeIntBool:
+		return fmt.Sprint(a.Data != 0)
+	case typeIntDec:
+		return fmt.Sprint(int32(a.Data))
+	case typeReference:
+		return fmt.Sprintf("@%08x", a.Data)
+	}
+	return fmt.Sprintf("0x%x", a.Data)
+}
+
+// Element returns the index in Nodes of the first element at path, such
+// as "manifest/application/activity", or -1.
+func (d *axmlDoc) Element(path string) int {
+	if els := d.Elements(path); len(els) > 0 {
+		return els[0]
+	}
+	return -1
+}
+
+// Elements returns the indexes in Nodes of every element at path.
+func (d *axmlDoc) Elements(path string) []int {
+	want := strings.Split(path, "/")
+	var stack []string
+	var found []int
+	for i, n := range d.Nodes {
+		switch n.Type {
+		case resXMLStartElemType:
+			stack = append(stack, d.str(n.Name))
+			if slices.Equal(stack, want) {
+				found = append(found, i)
+			}
+		case resXMLEndElemType:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return found
+}
+
+// Attr returns the value of the android: attribute name on the element at
+// index el, or of the plain attribute if name has no android ID, such as
+// the manifest's "package".
+func (d *axmlDoc) Attr(el int, name string) (string, bool) {
+	for _, a := range d.Nodes[el].Attrs {
+		if d.isAttr(a, name) {
+			return d.value(a), true
+		}
+	}
+	return "", false
+}
+
+func (d *axmlDoc) isAttr(a axmlAttr, name string) bool {
+	if id, ok := androidAttrs[name]; ok {
+		return int(a.Name) < len(d.ResIDs) && d.ResIDs[a.Name] == id
+	}
+	return a.NS == noIndex && d.str(a.Name) == name
+}
+
+// SetString sets a string attribute on the element at index el.
+func (d *axmlDoc) SetString(el int, name, value string) {
+	// Adding the name can renumber the strings after it, so the value is
+	// added once the name is in.
+	ns, key := d.attrKey(name)
+	s := d.addString(value)
+	d.setAttr(el, name, axmlAttr{NS: ns, Name: key, Raw: s, Type: typeString, Data: s})
+}
+
+// SetInt sets an integer attribute, such as an SDK version or an enum like
+// screenOrientation, on the element at index el.
+func (d *axmlDoc) SetInt(el int, name string, value int32) {
+	ns, key := d.attrKey(name)
+	d.setAttr(el, name, axmlAttr{NS: ns, Name: key, Raw: noIndex, Type: typeIntDec, Data: uint32(value)})
+}
+
+// SetBool sets a boolean attribute on the element at index el.
+func (d *axmlDoc) SetBool(el int, name string, value bool) {
+	var data uint32
+	if value {
+		data = 0xFFFFFFFF
+	}
+	ns, key := d.attrKey(name)
+	d.setAttr(el, name, axmlAttr{NS: ns, Name: key, Raw: noIndex, Type: typeIntBool, Data: data})
+}
+
+// attrKey returns the string indexes of the namespace and name of the
+// attribute name, adding them to the pool if needed.
+func (d *axmlDoc) attrKey(name string) (ns, key uint32) {
+	if _, ok := androidAttrs[name]; ok {
+		// The name is added first, as it can renumber other strings.
+		key = d.attrName(name)
+		return d.addString(androidNS), key
+	}
+	return noIndex, d.addString(name)
+}
+
+// setAttr replaces or adds the attribute a, whose NS and Name come from
+// attrKey. New attributes are kept in the order aapt writes them: android
+// attributes by resource ID, then the rest by name, as Android expects.
+func (d *axmlDoc) setAttr(el int, name string, a axmlAttr) {
+	n := &d.Nodes[el]
+	for i := range n.Attrs {
+		if d.isAttr(n.Attrs[i], name) {
+			n.Attrs[i] = a
+			return
+		}
+	}
+
+	// Special attributes are referred to by position; follow them.
+	special := []*uint16{&n.IDIndex, &n.ClassIndex, &n.StyleIndex}
+	var targets []axmlAttr
+	for _, p := range special {
+		if *p > 0 && int(*p) <= len(n.Attrs) {
+			targets = append(targets, n.Attrs[*p-1])
+		} else {
+			targets = append(targets, axmlAttr{})
+		}
+	}
+
+	n.Attrs = append(n.Attrs, a)
+	sort.SliceStable(n.Attrs, func(i, j int) bool { return d.attrLess(n.Attrs[i], n.Attrs[j]) })
+
+	for k, p := range special {
+		if *p == 0 {
+			continue
+		}
+		for i, at := range n.Attrs {
+			if at.NS == targets[k].NS && at.Name == targets[k].Name {
+				*p = uint16(i + 1)
+			}
+		}
+	}
+}
+
+func (d *axmlDoc) attrLess(a, b axmlAttr) bool {
+	ida, idb := d.resID(a.Name), d.resID(b.Name)
+	switch {
+	case ida != 0 && idb != 0:
+		return ida < idb
+	case ida != 0:
+		return true
+	case idb != 0:
+		return false
+	}
+	if na, nb := d.str(a.NS), d.str(b.NS); na != nb {
+		return na < nb
+	}
+	return d.str(a.Name) < d.str(b.Name)
+}
+
+func (d *axmlDoc) resID(name uint32) uint32 {
+	if int(name) < len(d.ResIDs) {
+		return d.ResIDs[name]
+	}
+	return 0
+}
+
+// addString returns the index of s in the string pool, adding it at the
+// end if needed.
+func (d *axmlDoc) addString(s string) uint32 {
+	for i := len(d.ResIDs); i < len(d.Strings); i++ {
+		if d.Strings[i] == s {
+			return uint32(i)
+		}
+	}
+	d.Strings = append(d.Strings, s)
+	return uint32(len(d.Strings) - 1)
+}
+
+// attrName returns the string index of the android attribute name, which
+// must lie in the part of the pool covered by the resource map. Adding one
+// inserts it at the end of that part and renumbers the strings after it.
+func (d *axmlDoc) attrName(name string) uint32 {
+	id := androidAttrs[name]
+	for i, rid := range d.ResIDs {
+		if rid == id {
+			return uint32(i)
+		}
+	}
+	at := uint32(len(d.ResIDs))
+	d.renumber(at)
+	d.Strings = append(d.Strings[:at], append([]string{name}, d.Strings[at:]...)...)
+	d.ResIDs = append(d.ResIDs, id)
+	return at
+}
+
+// renumber moves every string reference at or above from up by one.
+func (d *axmlDoc) renumber(from uint32) {
+	move := func(p *uint32) {
+		if *p != noIndex && *p >= from {
+			*p++
+		}
+	}
+	for i := range d.Nodes {
+		n := &d.Nodes[i]
+		move(&n.Comment)
+		switch n.Type {
+		case resXMLStartNSType, resXMLEndNSType:
+			move(&n.Prefix)
+			move(&n.URI)
+		case resXMLStartElemType, resXMLEndElemType:
+			move(&n.NS)
+			move(&n.Name)
+		case resXMLCDataType:
+			move(&n.CData)
+			if n.CDataType == typeString {
+				move(&n.CDataValue)
+			}
+		}
+		for j := range n.Attrs {
+			a := &n.Attrs[j]
+			move(&a.NS)
+			move(&a.Name)
+			move(&a.Raw)
+			if a.Type == typeString {
+				move(&a.Data)
+			}
+		}
+	}
+}
+
+// AddElement adds an empty element named name as the first child of the
+// element at index parent and returns its index.
+func (d *axmlDoc) AddElement(parent int, name string) int {
+	s := d.addString(name)
+	line := d.Nodes[parent].Line
+	start := axmlNode{Type: resXMLStartElemType, Line: line, Comment: noIndex, NS: noIndex, Name: s}
+	end := axmlNode{Type: resXMLEndElemType, Line: line, Comment: noIndex, NS: noIndex, Name: s}
+	at := parent + 1
+	d.Nodes = append(d.Nodes[:at], append([]axmlNode{start, end}, d.Nodes[at:]...)...)
+	return at
+}
+
+// AddPermission adds a uses-permission element for perm unless the
+// manifest already has one.
+func (d *axmlDoc) AddPermission(perm string) {
+	for _, el := range d.Elements("manifest/uses-permission") {
+		if name, _ := d.Attr(el, "name"); name == perm {
+			return
+		}
+	}
+	el := d.AddElement(d.Element("manifest"), "uses-permission")
+	d.SetString(el, "name", perm)
+}
+
+// checkRoundTrip checks that a document encodes back to something that
+// decodes the same, which catches any part of the format the patcher does
+// not handle before an APK is built with it.
+func checkRoundTrip(data []byte) error {
+	d, err := parseAXML(data)
+	if err != nil {
+		return err
+	}
+	again, err := parseAXML(d.Bytes())
+	if err != nil {
+		return fmt.Errorf("re-encoded manifest does not parse: %w", err)
+	}
+	if !bytes.Equal(again.Bytes(), d.Bytes()) || again.String() != d.String() {
+		return errors.New("re-encoded manifest differs from the original")
+	}
+	return nil
+}
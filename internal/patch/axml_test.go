@@ -0,0 +1,265 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// testdata/AndroidManifest.bin is internal/binres/testdata/bootstrap.bin
+// from golang.org/x/mobile: a gomobile manifest compiled by aapt.
+func readManifest(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/AndroidManifest.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func parseManifest(t *testing.T, data []byte) *axmlDoc {
+	t.Helper()
+	d, err := parseAXML(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// reparse encodes d and decodes the result, as Android would see it.
+func reparse(t *testing.T, d *axmlDoc) *axmlDoc {
+	t.Helper()
+	data := d.Bytes()
+	if err := checkRoundTrip(data); err != nil {
+		t.Fatal(err)
+	}
+	return parseManifest(t, data)
+}
+
+func attr(t *testing.T, d *axmlDoc, path, name string) string {
+	t.Helper()
+	el := d.Element(path)
+	if el < 0 {
+		t.Fatalf("no %s element", path)
+	}
+	v, ok := d.Attr(el, name)
+	if !ok {
+		t.Fatalf("%s has no %s attribute", path, name)
+	}
+	return v
+}
+
+// unchanged are attributes of the test manifest that no test edits, to
+// catch edits that renumber strings and break references to them.
+var unchanged = []struct{ path, name, want string }{
+	{"manifest", "package", "com.zentus.balloon"},
+	{"manifest", "versionCode", "42"},
+	{"manifest/application", "label", "Balloon世界"},
+	{"manifest/application", "foo", "bar"},
+	{"manifest/application/activity", "name", "android.app.NativeActivity"},
+	{"manifest/application/activity", "screenOrientation", "1"},
+	{"manifest/application/activity/meta-data", "name", "android.app.lib_name"},
+	{"manifest/application/activity/intent-filter/action", "name", "android.intent.action.MAIN"},
+}
+
+func checkUnchanged(t *testing.T, d *axmlDoc) {
+	t.Helper()
+	for _, u := range unchanged {
+		if got := attr(t, d, u.path, u.name); got != u.want {
+			t.Errorf("%s %s = %q, want %q", u.path, u.name, got, u.want)
+		}
+	}
+}
+
+func TestAXMLRoundTrip(t *testing.T) {
+	data := readManifest(t)
+	d := parseManifest(t, data)
+	if got := d.Bytes(); !bytes.Equal(got, data) {
+		t.Fatalf("re-encoded manifest is %d bytes and differs from the %d byte original", len(got), len(data))
+	}
+	checkUnchanged(t, d)
+}
+
+func permissions(d *axmlDoc) []string {
+	var perms []string
+	for _, el := range d.Elements("manifest/uses-permission") {
+		name, _ := d.Attr(el, "name")
+		perms = append(perms, name)
+	}
+	slices.Sort(perms)
+	return perms
+}
+
+func TestAXMLAddPermission(t *testing.T) {
+	d := parseManifest(t, readManifest(t))
+	d.AddPermission("android.permission.VIBRATE")
+	d.AddPermission("android.permission.INTERNET") // already there
+
+	d = reparse(t, d)
+	want := []string{"android.permission.INTERNET", "android.permission.VIBRATE"}
+	if got := permissions(d); !slices.Equal(got, want) {
+		t.Errorf("permissions = %q, want %q", got, want)
+	}
+	checkUnchanged(t, d)
+}
+
+func TestAXMLSetAttr(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(d *axmlDoc, el int)
+		path string
+		attr string
+		want string
+	}{
+		{
+			name: "replace string",
+			set:  func(d *axmlDoc, el int) { d.SetString(el, "versionName", "1.2") },
+			path: "manifest", attr: "versionName", want: "1.2",
+		},
+		{
+			name: "replace bool",
+			set:  func(d *axmlDoc, el int) { d.SetBool(el, "debuggable", false) },
+			path: "manifest/application", attr: "debuggable", want: "false",
+		},
+		{
+			// exported has no name in the pool yet, so this renumbers it.
+			name: "add bool",
+			set:  func(d *axmlDoc, el int) { d.SetBool(el, "exported", true) },
+			path: "manifest/application/activity", attr: "exported", want: "true",
+		},
+		{
+			name: "add int",
+			set:  func(d *axmlDoc, el int) { d.SetInt(el, "maxSdkVersion", 34) },
+			path: "manifest/uses-permission", attr: "maxSdkVersion", want: "34",
+		},
+		{
+			// The name goes into the pool ahead of the value, which must
+			// follow it.
+			name: "add android string",
+			set:  func(d *axmlDoc, el int) { d.SetString(el, "targetSdkVersion", "MyValue") },
+			path: "manifest/application", attr: "targetSdkVersion", want: "MyValue",
+		},
+		{
+			name: "replace plain string",
+			set:  func(d *axmlDoc, el int) { d.SetString(el, "baz", "qux") },
+			path: "manifest/application", attr: "baz", want: "qux",
+		},
+		{
+			name: "add plain string",
+			set:  func(d *axmlDoc, el int) { d.SetString(el, "extra", "value") },
+			path: "manifest/application/activity", attr: "extra", want: "value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := parseManifest(t, readManifest(t))
+			tt.set(d, d.Element(tt.path))
+			d = reparse(t, d)
+			if got := attr(t, d, tt.path, tt.attr); got != tt.want {
+				t.Errorf("%s %s = %q, want %q", tt.path, tt.attr, got, tt.want)
+			}
+			checkUnchanged(t, d)
+		})
+	}
+}
+
+func TestPatchManifest(t *testing.T) {
+	data, err := patchManifest(readManifest(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := parseManifest(t, data)
+	if got := attr(t, d, "manifest/uses-sdk", "targetSdkVersion"); got != "30" {
+		t.Errorf("targetSdkVersion = %s, want 30", got)
+	}
+	checkUnchanged(t, d)
+
+	// Patching again leaves a single uses-sdk.
+	again, err := patchManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Error("patching a patched manifest changed it")
+	}
+}
+
+func TestPatchAPK(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.apk")
+	dst := filepath.Join(dir, "out.apk")
+
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, data := range map[string][]byte{
+		"AndroidManifest.xml":  readManifest(t),
+		"classes.dex":          []byte("gomobile"),
+		"META-INF/CERT.SF":     []byte("signature"),
+		"lib/arm64-v8a/lib.so": []byte("go"),
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if err := patchAPK(src, dst, map[string][]byte{"classes2.dex": []byte("text input")}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.OpenReader(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	files := map[string][]byte{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	want := map[string]string{
+		"classes.dex":          "gomobile",
+		"classes2.dex":         "text input",
+		"lib/arm64-v8a/lib.so": "go",
+	}
+	for name, data := range want {
+		if got := string(files[name]); got != data {
+			t.Errorf("%s = %q, want %q", name, got, data)
+		}
+	}
+	if _, ok := files["META-INF/CERT.SF"]; ok {
+		t.Error("the old signature was kept")
+	}
+	d := parseManifest(t, files["AndroidManifest.xml"])
+	if got := attr(t, d, "manifest/uses-sdk", "targetSdkVersion"); got != "30" {
+		t.Errorf("targetSdkVersion = %s, want 30", got)
+	}
+}
+
+func TestNewestPlatform(t *testing.T) {
+	jars := []string{
+		filepath.Join("platforms", "android-36.1", "android.jar"),
+		filepath.Join("platforms", "android-Baklava", "android.jar"),
+		filepath.Join("platforms", "android-9", "android.jar"),
+		filepath.Join("platforms", "android-36", "android.jar"),
+	}
+	if got := filepath.Base(filepath.Dir(newestPlatform(jars))); got != "android-36.1" {
+		t.Errorf("newest platform = %s, want android-36.1", got)
+	}
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"archive/zip"
-	"bytes"
+	"cmp"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -22,14 +24,22 @@ func main() {
 
 	// Dynamic Build Tools detection
 	sdkVersion := "36.1.0"
-	buildToolsPath := filepath.Join(os.Getenv("LOCALAPPDATA"), "Android", "Sdk", "build-tools", sdkVersion)
+	sdkPath := filepath.Join(os.Getenv("LOCALAPPDATA"), "Android", "Sdk")
+	buildToolsPath := filepath.Join(sdkPath, "build-tools", sdkVersion)
 
 	apksigner := filepath.Join(buildToolsPath, "apksigner.bat")
 	zipalign := filepath.Join(buildToolsPath, "zipalign.exe")
 
 	// Step 1: Patching
-	fmt.Println("[*] Step 1: Brute-Force Patching to API 30...")
-	if err := patchAPK(originalAPK, outputAPK); err != nil {
+	fmt.Println("[*] Compiling the keyboard's text view...")
+	extra := map[string][]byte{}
+	if dex, err := buildTextInput(sdkPath, buildToolsPath); err != nil {
+		fmt.Printf("[!] Could not compile %s, swipe and voice typing will not reach the PC: %v\n", textInputSrc, err)
+	} else {
+		extra[textInputDex] = dex
+	}
+	fmt.Println("[*] Step 1: Patching the manifest...")
+	if err := patchAPK(originalAPK, outputAPK, extra); err != nil {
 		fmt.Printf("[-] Patch failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -60,7 +70,9 @@ func main() {
 	fmt.Printf("\n[+] SUCCESS! Created: %s\n", outputAPK)
 }
 
-func patchAPK(src, dst string) error {
+// patchAPK copies src to dst without its signature, patching the manifest
+// and adding the extra files by name.
+func patchAPK(src, dst string, extra map[string][]byte) error {
 	r, err := zip.OpenReader(src)
 	if err != nil {
 		return fmt.Errorf("could not open source APK %s: %w", src, err)
@@ -94,24 +106,109 @@ func patchAPK(src, dst string) error {
 
 		if f.Name == "AndroidManifest.xml" {
 			data, _ := io.ReadAll(rc)
-			oldPattern := []byte{0x08, 0x00, 0x00, 0x10, 0x10, 0x00, 0x00, 0x00}
-			newPattern := []byte{0x08, 0x00, 0x00, 0x10, 0x1E, 0x00, 0x00, 0x00}
-
-			patchedData := bytes.ReplaceAll(data, oldPattern, newPattern)
-			if bytes.Equal(data, patchedData) {
-				fmt.Println("[!] Warning: Pattern for API 16 not found!")
-			} else {
-				fmt.Println("[+] Successfully patched binary XML API patterns to version 30.")
+			patchedData, err := patchManifest(data)
+			if err != nil {
+				rc.Close()
+				return fmt.Errorf("could not patch AndroidManifest.xml: %w", err)
 			}
+			fmt.Printf("[+] Set targetSdkVersion to %d.\n", targetSdk)
 			fw.Write(patchedData)
 		} else {
 			io.Copy(fw, rc)
 		}
 		rc.Close()
 	}
+	for name, data := range extra {
+		fw, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// textInputSrc is the view the app's soft keyboard types into, see
+// internal/touchpad/ime_android.go. gomobile only builds Go, so the patch
+// compiles it into a second dex file, which Android loads along with
+// gomobile's classes.dex.
+const (
+	textInputSrc = "internal/touchpad/java/org/golang/todo/touchpad/TextInput.java"
+	textInputDex = "classes2.dex"
+)
+
+// buildTextInput compiles textInputSrc against the newest android.jar in
+// the SDK and returns the dex file.
+func buildTextInput(sdkPath, buildToolsPath string) ([]byte, error) {
+	jars, _ := filepath.Glob(filepath.Join(sdkPath, "platforms", "android-*", "android.jar"))
+	if len(jars) == 0 {
+		return nil, fmt.Errorf("no android.jar in %s", filepath.Join(sdkPath, "platforms"))
+	}
+	androidJar := newestPlatform(jars)
+
+	tmp, err := os.MkdirTemp("", "touchpad-java")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	classDir := filepath.Join(tmp, "classes")
+	javac := exec.Command("javac", "--release", "8", "-classpath", androidJar, "-d", classDir, textInputSrc)
+	if out, err := javac.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("javac: %v\n%s", err, out)
+	}
+	// Anonymous classes compile to files of their own
+	classes, _ := filepath.Glob(filepath.Join(classDir, "org", "golang", "todo", "touchpad", "*.class"))
+	args := []string{"--release", "--min-api", "30", "--lib", androidJar, "--output", tmp}
+	d8 := exec.Command(filepath.Join(buildToolsPath, "d8.bat"), append(args, classes...)...)
+	if out, err := d8.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("d8: %v\n%s", err, out)
+	}
+	return os.ReadFile(filepath.Join(tmp, "classes.dex"))
+}
+
+// newestPlatform returns the android.jar of the highest API level.
+func newestPlatform(jars []string) string {
+	return slices.MaxFunc(jars, func(a, b string) int {
+		return cmp.Compare(platformLevel(a), platformLevel(b))
+	})
+}
+
+// platformLevel returns the API level of an SDK platform's android.jar,
+// such as 36.1, or 0 for previews such as android-Baklava.
+func platformLevel(jar string) float64 {
+	level, _ := strconv.ParseFloat(strings.TrimPrefix(filepath.Base(filepath.Dir(jar)), "android-"), 64)
+	return level
+}
+
+// targetSdk is the API level the app declares it targets. Android refuses
+// to install apps targeting very old levels, which gomobile still emits.
+const targetSdk = 30
+
+// patchManifest raises the target SDK of a binary AndroidManifest.xml,
+// adding a uses-sdk element if there is none.
+func patchManifest(data []byte) ([]byte, error) {
+	if err := checkRoundTrip(data); err != nil {
+		return nil, err
+	}
+	doc, err := parseAXML(data)
+	if err != nil {
+		return nil, err
+	}
+	manifest := doc.Element("manifest")
+	if manifest < 0 {
+		return nil, fmt.Errorf("no manifest element")
+	}
+	sdk := doc.Element("manifest/uses-sdk")
+	if sdk < 0 {
+		sdk = doc.AddElement(manifest, "uses-sdk")
+	}
+	doc.SetInt(sdk, "targetSdkVersion", targetSdk)
+	return doc.Bytes(), nil
+}
+
 func generateKey(ks string) {
 	pw := "password"
 	runCmd("keytool", "-genkey", "-v", "-keystore", ks, "-alias", "dev", "-keyalg", "RSA", "-keysize", 2048, "-validity", "10000", "-storepass", pw, "-keypass", pw, "-dname", "CN=Touchpad", "-noprompt", "-deststoretype", "pkcs12")
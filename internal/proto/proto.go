@@ -0,0 +1,209 @@
+// Package proto is the message protocol between the host and the Android
+// app. The app listens on DevicePort on the phone's loopback interface and
+// the host reaches it through `adb forward`. Messages are JSON values, one
+// after another on the stream, starting with the host's Hello.
+//
+// Both sides send a Heartbeat every HeartbeatInterval. A side that hears
+// nothing for LinkTimeout treats the other as gone.
+package proto
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/mmngadi/touchpad-tool/internal/layout"
+)
+
+// DevicePort is the TCP port the app listens on, on the phone.
+const DevicePort = 27183
+
+// TokenExtra is the intent extra the host launches the app with. It holds
+// a token made up for the session, which the host repeats in its Hello so
+// that nothing else on the phone can take over the app's port.
+const TokenExtra = "touchpad_token"
+
+const (
+	HeartbeatInterval = time.Second
+	LinkTimeout       = 4 * time.Second
+)
+
+// Message types.
+const (
+	// Host to app: the first message on every connection, carrying the
+	// session Token. The app drops connections that start any other way.
+	Hello = "hello"
+
+	// Either direction: the sender is alive.
+	Heartbeat = "heartbeat"
+
+	// App to host: the app moved to Stage.
+	Lifecycle = "lifecycle"
+
+	// App to host: a hardware button went down or up, sent for the
+	// volume keys while ForwardVolume is on.
+	Key = "key"
+
+	// App to host: the phone's battery, sent when a host connects and
+	// then periodically.
+	Battery = "battery"
+
+	// App to host: the user paused or resumed the touchpad from the phone.
+	Pause  = "pause"
+	Resume = "resume"
+
+	// App to host: the size of the app's surface in pixels, sent when a
+	// host connects and whenever it changes.
+	Surface = "surface"
+
+	// App to host: one frame of touch input, sent while Capture is on.
+	Touch = "touch"
+
+	// Host to app: settings the app needs, sent whenever a host connects.
+	Config = "config"
+
+	// Host to app: what the gesture engine is doing now, for display.
+	Feedback = "feedback"
+
+	// Either direction, while clipboard sync is on: Text was copied.
+	Clipboard = "clipboard"
+
+	// Host to app: vibrate with Pattern at Amplitude.
+	Haptic = "haptic"
+
+	// App to host: Text was typed on the phone's keyboard, or Key names an
+	// editing key such as "backspace" or "enter" that was pressed.
+	Typed = "typed"
+)
+
+// Lifecycle stages.
+const (
+	StageForeground = "foreground" // focused and receiving touches
+	StageBackground = "background" // running but another app is in front
+	StageExit       = "exit"       // the user closed the app
+)
+
+// Modes reported with Feedback.
+const (
+	ModePointer = "pointer"
+	ModeScroll  = "scroll"
+	ModePinch   = "pinch"
+	ModeRotate  = "rotate"
+	ModeGesture = "gesture" // a three- or four-finger gesture
+	ModeDrag    = "drag"
+	ModePaused  = "paused"
+)
+
+// Display styles sent with Config.
+const (
+	DisplayNormal = "normal"
+	DisplayDim    = "dim" // everything drawn darker, to save OLED power
+	DisplayOff    = "off" // no touch or mode feedback at all
+)
+
+// Ways to close the app from the phone, sent as Exit with Config.
+const (
+	ExitDisabled = "disabled" // only the host can end the session
+	ExitDouble   = "double"   // press Back twice within two seconds
+	ExitLong     = "long"     // hold Back for a second
+	ExitVolume   = "volume"   // press both volume keys together
+)
+
+// Hardware buttons reported with Key.
+const (
+	KeyVolumeUp   = "volume-up"
+	KeyVolumeDown = "volume-down"
+)
+
+// Touch phases.
+const (
+	PhaseBegin = "begin"
+	PhaseMove  = "move"
+	PhaseEnd   = "end"
+)
+
+// Contact is one finger's part of a Touch frame. ID identifies the finger
+// from PhaseBegin to PhaseEnd; X and Y are pixels on the app's surface.
+type Contact struct {
+	ID    int     `json:"id"`
+	X     float32 `json:"x"`
+	Y     float32 `json:"y"`
+	Phase string  `json:"phase"`
+}
+
+// Message is a single message in either direction. Only the fields that
+// belong to Type are set.
+type Message struct {
+	Type   string         `json:"type"`
+	Stage  string         `json:"stage,omitempty"`
+	Layout *layout.Layout `json:"layout,omitempty"`
+	Mode   string         `json:"mode,omitempty"`
+
+	// Token is the session token from TokenExtra (Hello).
+	Token string `json:"token,omitempty"`
+
+	// Capture asks the app to send Touch frames, Display picks how it
+	// draws its feedback, Exit how the user closes it, and ForwardVolume
+	// has it send the volume keys as Key messages. LowPower stops it from
+	// repainting unless it has to, and WakeLock has it keep the screen on
+	// while it is in front. Clipboard is the largest text, in bytes, to
+	// share through clipboard sync, or 0 to leave the clipboard alone
+	// (Config).
+	Capture       bool   `json:"capture,omitempty"`
+	Display       string `json:"display,omitempty"`
+	Exit          string `json:"exit,omitempty"`
+	ForwardVolume bool   `json:"forward_volume,omitempty"`
+	LowPower      bool   `json:"low_power,omitempty"`
+	WakeLock      bool   `json:"wake_lock,omitempty"`
+	Clipboard     int    `json:"clipboard,omitempty"`
+
+	// Level is the battery charge in percent and Charging whether the
+	// phone is plugged in (Battery).
+	Level    int  `json:"level,omitempty"`
+	Charging bool `json:"charging,omitempty"`
+
+	// Text is copied text (Clipboard) or typed text (Typed).
+	Text string `json:"text,omitempty"`
+
+	// Key names a hardware button and Down says whether it was pressed or
+	// released (Key), or names an editing key (Typed).
+	Key  string `json:"key,omitempty"`
+	Down bool   `json:"down,omitempty"`
+
+	// Width and Height are the surface size in pixels (Surface).
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+
+	// Seq numbers Touch frames consecutively so the host can tell when
+	// some were lost; Time is when the frame was captured, in Unix
+	// nanoseconds on the phone's clock.
+	Seq      uint64    `json:"seq,omitempty"`
+	Time     int64     `json:"time,omitempty"`
+	Contacts []Contact `json:"contacts,omitempty"`
+
+	// Pattern is vibration timings in milliseconds, pauses and vibrations
+	// alternating and starting with a pause; Amplitude is the strength of
+	// the vibrations, 1 to 255 (Haptic).
+	Pattern   []int64 `json:"pattern,omitempty"`
+	Amplitude int     `json:"amplitude,omitempty"`
+}
+
+// Conn wraps a stream with message encoding and decoding.
+type Conn struct {
+	enc *json.Encoder
+	dec *json.Decoder
+}
+
+func NewConn(rw io.ReadWriter) *Conn {
+	return &Conn{enc: json.NewEncoder(rw), dec: json.NewDecoder(rw)}
+}
+
+func (c *Conn) Send(m Message) error {
+	return c.enc.Encode(m)
+}
+
+func (c *Conn) Receive() (Message, error) {
+	var m Message
+	err := c.dec.Decode(&m)
+	return m, err
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"time"
+
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+	"golang.org/x/mobile/app"
+	"golang.org/x/mobile/event/touch"
+)
+
+// touchBatchDelay is how long touch events are held to be sent together.
+const touchBatchDelay = 4 * time.Millisecond
+
+// flushTouches is sent into the event loop when a batch is due.
+type flushTouches struct{}
+
+// touchBatch collects touch events into frames for the host. Android
+// reports every pointer of a multi-finger move as its own event, so events
+// are held briefly and sent as one frame, like the kernel's SYN_REPORT.
+type touchBatch struct {
+	seq       uint64
+	start     int64
+	contacts  []proto.Contact
+	scheduled bool
+}
+
+func (b *touchBatch) add(a app.App, link *hostLink, e touch.Event) {
+	// A second event for the same finger belongs to the next frame.
+	for _, c := range b.contacts {
+		if c.ID == int(e.Sequence) {
+			b.flush(link)
+			break
+		}
+	}
+
+	phase := proto.PhaseMove
+	switch e.Type {
+	case touch.TypeBegin:
+		phase = proto.PhaseBegin
+	case touch.TypeEnd:
+		phase = proto.PhaseEnd
+	}
+	if len(b.contacts) == 0 {
+		b.start = time.Now().UnixNano()
+	}
+	b.contacts = append(b.contacts, proto.Contact{ID: int(e.Sequence), X: e.X, Y: e.Y, Phase: phase})
+
+	if !b.scheduled {
+		b.scheduled = true
+		time.AfterFunc(touchBatchDelay, func() { a.Send(flushTouches{}) })
+	}
+}
+
+// flush sends the collected events as one frame. Frames are numbered even
+// when no host is connected, so the host can tell that it missed some.
+func (b *touchBatch) flush(link *hostLink) {
+	if len(b.contacts) == 0 {
+		return
+	}
+	b.seq++
+	link.send(proto.Message{Type: proto.Touch, Seq: b.seq, Time: b.start, Contacts: b.contacts})
+	b.contacts = nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+)
+
+var errNoClipText = errors.New("clipboard holds no text")
+
+// clipboardPoll is how often the phone's clipboard is checked for changes.
+const clipboardPoll = time.Second
+
+// clipboardTick is sent into the event loop to check the clipboard.
+type clipboardTick struct{}
+
+// clipboardSync shares text copied on the phone with the host and puts
+// text copied on the host on the phone's clipboard. Only changes made
+// after sync was turned on are shared.
+type clipboardSync struct {
+	limit int    // largest text shared in bytes, 0 while sync is off
+	stamp int64  // clipboardStamp of the last clip looked at
+	last  string // text last copied on either side
+}
+
+func (c *clipboardSync) setLimit(limit int) {
+	if c.limit == 0 && limit > 0 {
+		c.stamp = clipboardStamp()
+		if c.stamp == -1 {
+			c.last, _ = readClipboard()
+		}
+	}
+	c.limit = limit
+}
+
+// set puts text from the host on the clipboard.
+func (c *clipboardSync) set(text string) {
+	if c.limit == 0 || len(text) > c.limit {
+		return
+	}
+	if writeClipboard(text) == nil {
+		c.last = text
+	}
+}
+
+// check sends the clipboard to the host if it changed. Android only lets
+// the app in front read the clipboard, so it is called only then.
+func (c *clipboardSync) check(link *hostLink) {
+	if c.limit == 0 {
+		return
+	}
+	stamp := clipboardStamp()
+	if stamp == c.stamp && stamp != -1 {
+		return
+	}
+	c.stamp = stamp
+	text, err := readClipboard()
+	if err != nil || text == "" || text == c.last || len(text) > c.limit {
+		return
+	}
+	c.last = text
+	link.send(proto.Message{Type: proto.Clipboard, Text: text})
+}
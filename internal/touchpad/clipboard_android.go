@@ -0,0 +1,167 @@
+//go:build android
+
+package main
+
+/*
+#include <stdlib.h>
+#include "jni_android.h"
+
+// set_clipboard makes text (UTF-16) the primary clip. It returns 0 on
+// success.
+static int set_clipboard(uintptr_t jni_env, uintptr_t jctx, const jchar *text, jsize n) {
+	JNIEnv *env = (JNIEnv *)jni_env;
+	if ((*env)->PushLocalFrame(env, 16) < 0) {
+		return -1;
+	}
+	jobject cm = system_service(env, (jobject)jctx, "clipboard");
+	if (clear_exception(env) || cm == NULL) {
+		(*env)->PopLocalFrame(env, NULL);
+		return -1;
+	}
+	jclass clipData = (*env)->FindClass(env, "android/content/ClipData");
+	jmethodID newPlainText = (*env)->GetStaticMethodID(env, clipData, "newPlainText", "(Ljava/lang/CharSequence;Ljava/lang/CharSequence;)Landroid/content/ClipData;");
+	jobject clip = (*env)->CallStaticObjectMethod(env, clipData, newPlainText, (*env)->NewStringUTF(env, "touchpad"), (*env)->NewString(env, text, n));
+	if (clear_exception(env) || clip == NULL) {
+		(*env)->PopLocalFrame(env, NULL);
+		return -1;
+	}
+	jmethodID setClip = (*env)->GetMethodID(env, (*env)->GetObjectClass(env, cm), "setPrimaryClip", "(Landroid/content/ClipData;)V");
+	(*env)->CallVoidMethod(env, cm, setClip, clip);
+	int ret = clear_exception(env);
+	(*env)->PopLocalFrame(env, NULL);
+	return ret;
+}
+
+// clipboard_stamp returns when the primary clip was set, without reading
+// it: Android 12 and later show a toast every time an app reads the
+// clipboard. It returns 0 when there is no clip and -1 when the timestamp
+// is not available (before API 26).
+static jlong clipboard_stamp(uintptr_t jni_env, uintptr_t jctx) {
+	JNIEnv *env = (JNIEnv *)jni_env;
+	if ((*env)->PushLocalFrame(env, 16) < 0) {
+		return -1;
+	}
+	jlong stamp = -1;
+	jobject cm = system_service(env, (jobject)jctx, "clipboard");
+	if (!clear_exception(env) && cm != NULL) {
+		jmethodID getDesc = (*env)->GetMethodID(env, (*env)->GetObjectClass(env, cm), "getPrimaryClipDescription", "()Landroid/content/ClipDescription;");
+		jobject desc = (*env)->CallObjectMethod(env, cm, getDesc);
+		if (!clear_exception(env)) {
+			stamp = 0;
+			if (desc != NULL) {
+				jmethodID getStamp = (*env)->GetMethodID(env, (*env)->GetObjectClass(env, desc), "getTimestamp", "()J");
+				stamp = getStamp != NULL ? (*env)->CallLongMethod(env, desc, getStamp) : -1;
+			}
+		}
+	}
+	if (clear_exception(env)) {
+		stamp = -1;
+	}
+	(*env)->PopLocalFrame(env, NULL);
+	return stamp;
+}
+
+// get_clipboard copies the text of the primary clip, as UTF-16, into a
+// buffer the caller frees. It returns 0 on success, 1 when the clipboard
+// holds no text and -1 on error.
+static int get_clipboard(uintptr_t jni_env, uintptr_t jctx, jchar **text, jsize *n) {
+	JNIEnv *env = (JNIEnv *)jni_env;
+	if ((*env)->PushLocalFrame(env, 16) < 0) {
+		return -1;
+	}
+	int ret = -1;
+	jobject cm = system_service(env, (jobject)jctx, "clipboard");
+	if (clear_exception(env) || cm == NULL) {
+		goto out;
+	}
+	jmethodID getClip = (*env)->GetMethodID(env, (*env)->GetObjectClass(env, cm), "getPrimaryClip", "()Landroid/content/ClipData;");
+	jobject clip = (*env)->CallObjectMethod(env, cm, getClip);
+	if (clear_exception(env)) {
+		goto out;
+	}
+	ret = 1;
+	if (clip == NULL) {
+		goto out;
+	}
+	jclass clipClass = (*env)->GetObjectClass(env, clip);
+	jint count = (*env)->CallIntMethod(env, clip, (*env)->GetMethodID(env, clipClass, "getItemCount", "()I"));
+	if (count < 1) {
+		goto out;
+	}
+	jobject item = (*env)->CallObjectMethod(env, clip, (*env)->GetMethodID(env, clipClass, "getItemAt", "(I)Landroid/content/ClipData$Item;"), 0);
+	jobject cs = (*env)->CallObjectMethod(env, item, (*env)->GetMethodID(env, (*env)->GetObjectClass(env, item), "getText", "()Ljava/lang/CharSequence;"));
+	if (cs == NULL) {
+		goto out;
+	}
+	jstring str = (*env)->CallObjectMethod(env, cs, (*env)->GetMethodID(env, (*env)->GetObjectClass(env, cs), "toString", "()Ljava/lang/String;"));
+	if (clear_exception(env) || str == NULL) {
+		ret = -1;
+		goto out;
+	}
+	*n = (*env)->GetStringLength(env, str);
+	*text = malloc((*n + 1) * sizeof(jchar));
+	(*env)->GetStringRegion(env, str, 0, *n, *text);
+	ret = 0;
+out:
+	if (clear_exception(env)) {
+		ret = -1;
+	}
+	(*env)->PopLocalFrame(env, NULL);
+	return ret;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/mobile/app"
+)
+
+// writeClipboard makes text the phone's clipboard.
+func writeClipboard(text string) error {
+	u := utf16.Encode([]rune(text))
+	if len(u) == 0 {
+		return nil
+	}
+	return app.RunOnJVM(func(vm, env, ctx uintptr) error {
+		if C.set_clipboard(C.uintptr_t(env), C.uintptr_t(ctx), (*C.jchar)(unsafe.Pointer(&u[0])), C.jsize(len(u))) != 0 {
+			return errors.New("clipboard unavailable")
+		}
+		return nil
+	})
+}
+
+// clipboardStamp identifies the current clip without reading it. It is
+// -1 when Android cannot tell, in which case the clip must be read.
+func clipboardStamp() int64 {
+	var stamp C.jlong = -1
+	app.RunOnJVM(func(vm, env, ctx uintptr) error {
+		stamp = C.clipboard_stamp(C.uintptr_t(env), C.uintptr_t(ctx))
+		return nil
+	})
+	return int64(stamp)
+}
+
+// readClipboard returns the text on the phone's clipboard.
+func readClipboard() (string, error) {
+	var p *C.jchar
+	var n C.jsize
+	var r C.int
+	err := app.RunOnJVM(func(vm, env, ctx uintptr) error {
+		r = C.get_clipboard(C.uintptr_t(env), C.uintptr_t(ctx), &p, &n)
+		return nil
+	})
+	switch {
+	case err != nil:
+		return "", err
+	case r < 0:
+		return "", errors.New("clipboard unavailable")
+	case r > 0:
+		return "", errNoClipText
+	}
+	defer C.free(unsafe.Pointer(p))
+	return string(utf16.Decode(unsafe.Slice((*uint16)(unsafe.Pointer(p)), int(n)))), nil
+}
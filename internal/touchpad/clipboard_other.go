@@ -0,0 +1,11 @@
+//go:build !android
+
+package main
+
+import "errors"
+
+// The phone's clipboard only exists on Android.
+
+func writeClipboard(text string) error { return errors.New("no clipboard") }
+func clipboardStamp() int64            { return 0 }
+func readClipboard() (string, error)   { return "", errNoClipText }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"github.com/mmngadi/touchpad-tool/internal/layout"
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+	"golang.org/x/mobile/event/size"
+	"golang.org/x/mobile/event/touch"
+	"golang.org/x/mobile/gl"
+)
+
+// brightness scales every color drawn. The dim display style lowers it,
+// since on OLED panels a darker pixel draws less power.
+var brightness float32 = 1
+
+// displayBrightness maps a proto display style to a brightness.
+func displayBrightness(display string) float32 {
+	if display == proto.DisplayDim {
+		return 0.35
+	}
+	return 1
+}
+
+// drawZones outlines each button zone in dim gray. Zones are in pad space,
+// which matches the landscape surface the host rotates us into.
+func drawZones(glctx gl.Context, sz size.Event, zones []layout.Zone) {
+	const line = 3 // px
+	for _, z := range zones {
+		x0 := int(z.Left * float64(sz.WidthPx))
+		x1 := int(z.Right * float64(sz.WidthPx))
+		y0 := int(z.Top * float64(sz.HeightPx))
+		y1 := int(z.Bottom * float64(sz.HeightPx))
+
+		fillRect(glctx, sz, x0, y0, x1-x0, line)
+		fillRect(glctx, sz, x0, y1-line, x1-x0, line)
+		fillRect(glctx, sz, x0, y0, line, y1-y0)
+		fillRect(glctx, sz, x1-line, y0, line, y1-y0)
+	}
+}
+
+// drawPauseButton draws two bars while running, and fills the button in
+// dim green while paused so it is obvious how to resume.
+func drawPauseButton(glctx gl.Context, sz size.Event, paused bool) {
+	b := layout.PauseButton
+	x0 := int(b.Left * float64(sz.WidthPx))
+	x1 := int(b.Right * float64(sz.WidthPx))
+	y0 := int(b.Top * float64(sz.HeightPx))
+	y1 := int(b.Bottom * float64(sz.HeightPx))
+	w, h := x1-x0, y1-y0
+
+	if paused {
+		fillRectColor(glctx, sz, x0, y0, w, h, 0, 0.25, 0.1)
+		return
+	}
+	fillRect(glctx, sz, x0+w/4, y0+h/4, w/6, h/2)
+	fillRect(glctx, sz, x1-w/4-w/6, y0+h/4, w/6, h/2)
+}
+
+// drawKeyboardButton draws a small keyboard, on a dim blue background
+// while typing.
+func drawKeyboardButton(glctx gl.Context, sz size.Event, typing bool) {
+	b := layout.KeyboardButton
+	x0 := int(b.Left * float64(sz.WidthPx))
+	x1 := int(b.Right * float64(sz.WidthPx))
+	y0 := int(b.Top * float64(sz.HeightPx))
+	y1 := int(b.Bottom * float64(sz.HeightPx))
+	w, h := x1-x0, y1-y0
+
+	if typing {
+		fillRectColor(glctx, sz, x0, y0, w, h, 0, 0.1, 0.3)
+	}
+	// Two rows of keys above a space bar
+	k := w / 8
+	for row := 0; row < 2; row++ {
+		for col := 0; col < 3; col++ {
+			fillRect(glctx, sz, x0+w/4+col*(k+k/2), y0+h/4+row*(k+k/2), k, k)
+		}
+	}
+	fillRect(glctx, sz, x0+w/4, y0+h/4+3*k, 4*k, k)
+}
+
+// drawStatus shows whether the host is connected with a dot in the top-left
+// corner.
+func drawStatus(glctx gl.Context, sz size.Event, connected bool) {
+	dot := sz.HeightPx / 40
+	if connected {
+		fillRectColor(glctx, sz, dot, dot, dot, dot, 0, 0.3, 0.1)
+	} else {
+		fillRectColor(glctx, sz, dot, dot, dot, dot, 0.35, 0, 0)
+	}
+}
+
+// drawMode shows what the gesture engine is doing with a strip along the
+// top, next to the connection dot.
+func drawMode(glctx gl.Context, sz size.Event, mode string) {
+	dot := sz.HeightPx / 40
+	x0 := 3 * dot
+	x1 := int(layout.KeyboardButton.Left*float64(sz.WidthPx)) - dot
+	if r, g, b, ok := modeColor(mode); ok {
+		fillRectColor(glctx, sz, x0, dot, x1-x0, dot/2, r, g, b)
+	}
+}
+
+// drawContacts outlines a square around every finger on the screen, in the
+// color of the current mode so a misrecognized gesture is easy to spot.
+func drawContacts(glctx gl.Context, sz size.Event, touches map[touch.Sequence]touch.Event, mode string) {
+	r, g, b, ok := modeColor(mode)
+	if !ok {
+		r, g, b = 0.3, 0.3, 0.3
+	}
+	side := sz.HeightPx / 8
+	line := side / 12
+	for _, t := range touches {
+		x0, y0 := int(t.X)-side/2, int(t.Y)-side/2
+		fillRectColor(glctx, sz, x0, y0, side, line, r, g, b)
+		fillRectColor(glctx, sz, x0, y0+side-line, side, line, r, g, b)
+		fillRectColor(glctx, sz, x0, y0, line, side, r, g, b)
+		fillRectColor(glctx, sz, x0+side-line, y0, line, side, r, g, b)
+	}
+}
+
+// modeColor is the color each engine mode is shown in. Plain pointer
+// movement has none, so the screen stays dark most of the time.
+func modeColor(mode string) (r, g, b float32, ok bool) {
+	switch mode {
+	case proto.ModeScroll:
+		return 0, 0.15, 0.35, true
+	case proto.ModePinch:
+		return 0, 0.3, 0.3, true
+	case proto.ModeRotate:
+		return 0.3, 0.3, 0, true
+	case proto.ModeGesture:
+		return 0.25, 0, 0.35, true
+	case proto.ModeDrag:
+		return 0.35, 0.2, 0, true
+	case proto.ModePaused:
+		return 0.15, 0.15, 0.15, true
+	}
+	return 0, 0, 0, false
+}
+
+// fillRect paints a solid rectangle given in top-left pixel coordinates.
+// A scissored clear is all we need for flat shapes, so no shaders are set up.
+func fillRect(glctx gl.Context, sz size.Event, x, y, w, h int) {
+	fillRectColor(glctx, sz, x, y, w, h, 0.15, 0.15, 0.15)
+}
+
+func fillRectColor(glctx gl.Context, sz size.Event, x, y, w, h int, r, g, b float32) {
+	glctx.Enable(gl.SCISSOR_TEST)
+	glctx.Scissor(int32(x), int32(sz.HeightPx-y-h), int32(w), int32(h))
+	glctx.ClearColor(r*brightness, g*brightness, b*brightness, 1)
+	glctx.Clear(gl.COLOR_BUFFER_BIT)
+	glctx.Disable(gl.SCISSOR_TEST)
+}
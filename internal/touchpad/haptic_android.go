@@ -0,0 +1,79 @@
+//go:build android
+
+package main
+
+/*
+#include "jni_android.h"
+
+// vibrate plays a waveform through the Vibrator system service. timings
+// alternate pauses and vibrations in milliseconds; amplitudes holds the
+// strength of each, 0 for the pauses. It returns 0 on success.
+static int vibrate(uintptr_t jni_env, uintptr_t jctx, jlong *timings, jint *amplitudes, jsize n) {
+	JNIEnv *env = (JNIEnv *)jni_env;
+	jobject ctx = (jobject)jctx;
+	if ((*env)->PushLocalFrame(env, 16) < 0) {
+		return -1;
+	}
+
+	jobject vibrator = system_service(env, ctx, "vibrator");
+	if (clear_exception(env) || vibrator == NULL) {
+		(*env)->PopLocalFrame(env, NULL);
+		return -1;
+	}
+	jclass vibClass = (*env)->GetObjectClass(env, vibrator);
+	jlongArray jtimings = (*env)->NewLongArray(env, n);
+	(*env)->SetLongArrayRegion(env, jtimings, 0, n, timings);
+
+	jclass effectClass = (*env)->FindClass(env, "android/os/VibrationEffect");
+	if (effectClass == NULL) {
+		// Before API 26 there is no amplitude control, only the pattern.
+		(*env)->ExceptionClear(env);
+		jmethodID vib = (*env)->GetMethodID(env, vibClass, "vibrate", "([JI)V");
+		(*env)->CallVoidMethod(env, vibrator, vib, jtimings, -1);
+	} else {
+		jintArray jamps = (*env)->NewIntArray(env, n);
+		(*env)->SetIntArrayRegion(env, jamps, 0, n, amplitudes);
+		jmethodID create = (*env)->GetStaticMethodID(env, effectClass, "createWaveform", "([J[II)Landroid/os/VibrationEffect;");
+		jobject effect = (*env)->CallStaticObjectMethod(env, effectClass, create, jtimings, jamps, -1);
+		if (!(*env)->ExceptionCheck(env) && effect != NULL) {
+			jmethodID vib = (*env)->GetMethodID(env, vibClass, "vibrate", "(Landroid/os/VibrationEffect;)V");
+			(*env)->CallVoidMethod(env, vibrator, vib, effect);
+		}
+	}
+
+	int ret = clear_exception(env);
+	(*env)->PopLocalFrame(env, NULL);
+	return ret;
+}
+*/
+import "C"
+
+import (
+	"errors"
+
+	"golang.org/x/mobile/app"
+)
+
+// vibrate plays pattern, pauses and vibrations alternating in milliseconds,
+// with the vibrations at amplitude (1-255). It blocks until Android has
+// taken the request, so callers run it on its own goroutine.
+func vibrate(pattern []int64, amplitude int) error {
+	n := len(pattern)
+	if n == 0 {
+		return nil
+	}
+	timings := make([]C.jlong, n)
+	amps := make([]C.jint, n)
+	for i, t := range pattern {
+		timings[i] = C.jlong(t)
+		if i%2 == 1 {
+			amps[i] = C.jint(amplitude)
+		}
+	}
+	return app.RunOnJVM(func(vm, env, ctx uintptr) error {
+		if C.vibrate(C.uintptr_t(env), C.uintptr_t(ctx), &timings[0], &amps[0], C.jsize(n)) != 0 {
+			return errors.New("vibrator unavailable")
+		}
+		return nil
+	})
+}
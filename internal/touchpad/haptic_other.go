@@ -0,0 +1,8 @@
+//go:build !android
+
+package main
+
+// vibrate does nothing off Android, where the app only runs for development.
+func vibrate(pattern []int64, amplitude int) error {
+	return nil
+}
@@ -0,0 +1,128 @@
+//go:build android
+
+package main
+
+/*
+#include "jni_android.h"
+
+// Implemented in Go, see ime_text_android.go.
+extern void imeCommitted(jchar *chars, jsize n);
+extern void imeDeleted(jint before, jint after);
+
+static void text_committed(JNIEnv *env, jclass cls, jstring text) {
+	jsize n = (*env)->GetStringLength(env, text);
+	const jchar *chars = (*env)->GetStringChars(env, text, NULL);
+	if (chars == NULL) {
+		return;
+	}
+	imeCommitted((jchar *)chars, n);
+	(*env)->ReleaseStringChars(env, text, chars);
+}
+
+static void text_deleted(JNIEnv *env, jclass cls, jint before, jint after) {
+	imeDeleted(before, after);
+}
+
+// text_input returns the app's TextInput class with its native methods
+// registered, or NULL if the APK was built without it. The class is not in
+// gomobile's dex, so it has to come from the activity's class loader.
+static jclass text_input(JNIEnv *env, jobject ctx) {
+	static JNINativeMethod natives[] = {
+		{"committed", "(Ljava/lang/String;)V", (void *)text_committed},
+		{"deleted", "(II)V", (void *)text_deleted},
+	};
+	jclass ctxClass = (*env)->GetObjectClass(env, ctx);
+	jmethodID getLoader = (*env)->GetMethodID(env, ctxClass, "getClassLoader", "()Ljava/lang/ClassLoader;");
+	jobject loader = (*env)->CallObjectMethod(env, ctx, getLoader);
+	if (clear_exception(env) || loader == NULL) {
+		return NULL;
+	}
+	jclass loaderClass = (*env)->GetObjectClass(env, loader);
+	jmethodID loadClass = (*env)->GetMethodID(env, loaderClass, "loadClass", "(Ljava/lang/String;)Ljava/lang/Class;");
+	jstring name = (*env)->NewStringUTF(env, "org.golang.todo.touchpad.TextInput");
+	jclass cls = (jclass)(*env)->CallObjectMethod(env, loader, loadClass, name);
+	if (clear_exception(env) || cls == NULL) {
+		return NULL;
+	}
+	if ((*env)->RegisterNatives(env, cls, natives, 2) != 0) {
+		clear_exception(env);
+		return NULL;
+	}
+	return cls;
+}
+
+// show_keyboard opens or closes the soft keyboard over the activity. The
+// keyboard types into a TextInput view, which passes text on to
+// imeCommitted, while keys such as Enter arrive as key events. Without the
+// view the keyboard types into the activity's own view, which takes only
+// key events, so text typed a word at a time is lost. It returns 0 on
+// success.
+static int show_keyboard(uintptr_t jni_env, uintptr_t jctx, int show) {
+	JNIEnv *env = (JNIEnv *)jni_env;
+	jobject ctx = (jobject)jctx;
+	if ((*env)->PushLocalFrame(env, 16) < 0) {
+		return -1;
+	}
+	jclass input = text_input(env, ctx);
+	if (input != NULL) {
+		jmethodID m = (*env)->GetStaticMethodID(env, input, "show", "(Landroid/app/Activity;Z)V");
+		if (m != NULL) {
+			(*env)->CallStaticVoidMethod(env, input, m, ctx, (jboolean)(show != 0));
+		}
+		int ret = clear_exception(env);
+		(*env)->PopLocalFrame(env, NULL);
+		return ret;
+	}
+	jobject imm = system_service(env, ctx, "input_method");
+	if (clear_exception(env) || imm == NULL) {
+		(*env)->PopLocalFrame(env, NULL);
+		return -1;
+	}
+	jclass actClass = (*env)->GetObjectClass(env, ctx);
+	jmethodID getWindow = (*env)->GetMethodID(env, actClass, "getWindow", "()Landroid/view/Window;");
+	jobject window = (*env)->CallObjectMethod(env, ctx, getWindow);
+	jclass winClass = (*env)->GetObjectClass(env, window);
+	jmethodID getDecorView = (*env)->GetMethodID(env, winClass, "getDecorView", "()Landroid/view/View;");
+	jobject view = (*env)->CallObjectMethod(env, window, getDecorView);
+	if (clear_exception(env) || view == NULL) {
+		(*env)->PopLocalFrame(env, NULL);
+		return -1;
+	}
+
+	jclass immClass = (*env)->GetObjectClass(env, imm);
+	if (show) {
+		jmethodID m = (*env)->GetMethodID(env, immClass, "showSoftInput", "(Landroid/view/View;I)Z");
+		(*env)->CallBooleanMethod(env, imm, m, view, 2); // SHOW_FORCED
+	} else {
+		jclass viewClass = (*env)->GetObjectClass(env, view);
+		jmethodID getToken = (*env)->GetMethodID(env, viewClass, "getWindowToken", "()Landroid/os/IBinder;");
+		jobject token = (*env)->CallObjectMethod(env, view, getToken);
+		jmethodID m = (*env)->GetMethodID(env, immClass, "hideSoftInputFromWindow", "(Landroid/os/IBinder;I)Z");
+		(*env)->CallBooleanMethod(env, imm, m, token, 0);
+	}
+	int ret = clear_exception(env);
+	(*env)->PopLocalFrame(env, NULL);
+	return ret;
+}
+*/
+import "C"
+
+import (
+	"errors"
+
+	"golang.org/x/mobile/app"
+)
+
+// showKeyboard opens or closes the phone's soft keyboard.
+func showKeyboard(show bool) error {
+	var flag C.int
+	if show {
+		flag = 1
+	}
+	return app.RunOnJVM(func(vm, env, ctx uintptr) error {
+		if C.show_keyboard(C.uintptr_t(env), C.uintptr_t(ctx), flag) != 0 {
+			return errors.New("soft keyboard unavailable")
+		}
+		return nil
+	})
+}
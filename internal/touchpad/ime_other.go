@@ -0,0 +1,8 @@
+//go:build !android
+
+package main
+
+// showKeyboard does nothing off Android.
+func showKeyboard(show bool) error {
+	return nil
+}
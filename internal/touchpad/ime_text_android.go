@@ -0,0 +1,25 @@
+//go:build android
+
+package main
+
+// These are called by TextInput's native methods, registered in
+// ime_android.go, on Android's UI thread.
+
+// #include <jni.h>
+import "C"
+
+import (
+	"unicode/utf16"
+	"unsafe"
+)
+
+//export imeCommitted
+func imeCommitted(chars *C.jchar, n C.jsize) {
+	text := utf16.Decode(unsafe.Slice((*uint16)(unsafe.Pointer(chars)), int(n)))
+	keyboardText <- imeText{text: string(text)}
+}
+
+//export imeDeleted
+func imeDeleted(before, after C.jint) {
+	keyboardText <- imeText{before: int(before), after: int(after)}
+}
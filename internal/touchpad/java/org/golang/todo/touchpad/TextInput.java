@@ -0,0 +1,120 @@
+package org.golang.todo.touchpad;
+
+import android.app.Activity;
+import android.content.Context;
+import android.text.Editable;
+import android.text.InputType;
+import android.view.View;
+import android.view.ViewGroup;
+import android.view.inputmethod.BaseInputConnection;
+import android.view.inputmethod.EditorInfo;
+import android.view.inputmethod.InputConnection;
+import android.view.inputmethod.InputMethodManager;
+
+// TextInput is an invisible view the soft keyboard types into. The
+// activity's own view takes key events only, so a keyboard that enters
+// text a word at a time, as swipe and voice typing do, has nowhere to put
+// it. This view gives the keyboard an input connection and passes what it
+// commits on to the app. Keys such as Enter and Backspace still reach the
+// app as key events.
+//
+// The app registers the native methods and calls show over JNI, see
+// ime_android.go. internal/patch compiles this file into the APK.
+public class TextInput extends View {
+	private static TextInput view;
+
+	// committed hands text the keyboard entered to the app.
+	static native void committed(String text);
+
+	// deleted asks the app to delete characters around the cursor.
+	static native void deleted(int before, int after);
+
+	TextInput(Context ctx) {
+		super(ctx);
+		setFocusable(true);
+		setFocusableInTouchMode(true);
+	}
+
+	@Override
+	public boolean onCheckIsTextEditor() {
+		return true;
+	}
+
+	@Override
+	public InputConnection onCreateInputConnection(EditorInfo info) {
+		info.inputType = InputType.TYPE_CLASS_TEXT | InputType.TYPE_TEXT_FLAG_MULTI_LINE;
+		info.imeOptions = EditorInfo.IME_FLAG_NO_FULLSCREEN | EditorInfo.IME_FLAG_NO_EXTRACT_UI;
+		return new Connection(this);
+	}
+
+	// show opens or closes the soft keyboard over the activity, adding the
+	// view to it the first time.
+	public static void show(final Activity activity, final boolean show) {
+		activity.runOnUiThread(new Runnable() {
+			@Override
+			public void run() {
+				if (view == null || view.getContext() != activity) {
+					view = new TextInput(activity);
+					activity.addContentView(view, new ViewGroup.LayoutParams(1, 1));
+				}
+				final InputMethodManager imm = (InputMethodManager) activity.getSystemService(Context.INPUT_METHOD_SERVICE);
+				if (!show) {
+					imm.hideSoftInputFromWindow(view.getWindowToken(), 0);
+					view.clearFocus();
+					return;
+				}
+				view.requestFocus();
+				// A view added just now is not attached until the next layout.
+				view.post(new Runnable() {
+					@Override
+					public void run() {
+						imm.showSoftInput(view, InputMethodManager.SHOW_FORCED);
+					}
+				});
+			}
+		});
+	}
+
+	// Connection passes committed text on to the app instead of keeping it:
+	// the text lives on the PC. Only the word being composed stays in the
+	// editable until the keyboard commits it.
+	private static class Connection extends BaseInputConnection {
+		Connection(View view) {
+			super(view, false);
+		}
+
+		@Override
+		public boolean commitText(CharSequence text, int newCursorPosition) {
+			clear();
+			if (text.length() > 0) {
+				committed(text.toString());
+			}
+			return true;
+		}
+
+		@Override
+		public boolean finishComposingText() {
+			Editable content = getEditable();
+			String text = content.toString();
+			clear();
+			if (!text.isEmpty()) {
+				committed(text);
+			}
+			return true;
+		}
+
+		@Override
+		public boolean deleteSurroundingText(int beforeLength, int afterLength) {
+			if (beforeLength > 0 || afterLength > 0) {
+				deleted(beforeLength, afterLength);
+			}
+			return true;
+		}
+
+		private void clear() {
+			Editable content = getEditable();
+			removeComposingSpans(content);
+			content.clear();
+		}
+	}
+}
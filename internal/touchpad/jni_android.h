@@ -0,0 +1,21 @@
+// Helpers shared by the app's JNI calls into the Android framework.
+
+#include <jni.h>
+#include <stdint.h>
+
+// system_service returns Context.getSystemService(name).
+static inline jobject system_service(JNIEnv *env, jobject ctx, const char *name) {
+	jclass ctxClass = (*env)->GetObjectClass(env, ctx);
+	jmethodID getService = (*env)->GetMethodID(env, ctxClass, "getSystemService", "(Ljava/lang/String;)Ljava/lang/Object;");
+	return (*env)->CallObjectMethod(env, ctx, getService, (*env)->NewStringUTF(env, name));
+}
+
+// clear_exception clears a pending Java exception. It returns -1 if there
+// was one and 0 otherwise.
+static inline int clear_exception(JNIEnv *env) {
+	if ((*env)->ExceptionCheck(env)) {
+		(*env)->ExceptionClear(env);
+		return -1;
+	}
+	return 0;
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"time"
+
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+	"golang.org/x/mobile/event/key"
+)
+
+// backHold is how long Back must be held to exit with proto.ExitLong.
+const backHold = time.Second
+
+// phoneKeys follows the phone's Back and volume keys to decide when the
+// user asked to exit, in the way the host configured.
+type phoneKeys struct {
+	exit           string    // a proto.Exit* style
+	lastBack       time.Time // release of the previous Back press
+	backDown       time.Time // zero while Back is up
+	volUp, volDown bool
+}
+
+// back handles a Back event and reports whether the app should exit.
+// Android repeats the press while the key is held.
+func (k *phoneKeys) back(dir key.Direction, now time.Time) bool {
+	switch dir {
+	case key.DirPress:
+		if k.backDown.IsZero() {
+			k.backDown = now
+		}
+		return k.exit == proto.ExitLong && now.Sub(k.backDown) >= backHold
+	case key.DirRelease:
+		held := now.Sub(k.backDown)
+		k.backDown = time.Time{}
+		switch k.exit {
+		case proto.ExitDouble:
+			if now.Sub(k.lastBack) < 2*time.Second {
+				return true
+			}
+			k.lastBack = now
+		case proto.ExitLong:
+			return held >= backHold
+		}
+	}
+	return false
+}
+
+// volume handles a volume key going down or up. It reports whether the key
+// changed state, ignoring Android's repeats, and whether the app should
+// exit because both keys are down.
+func (k *phoneKeys) volume(code key.Code, down bool) (changed, exit bool) {
+	state := &k.volDown
+	if code == key.CodeVolumeUp {
+		state = &k.volUp
+	}
+	changed = *state != down
+	*state = down
+	return changed, k.exit == proto.ExitVolume && k.volUp && k.volDown
+}
+
+// editingKeys are the keys without a character that are typed on the PC
+// while the keyboard is open, by their host key name.
+var editingKeys = map[key.Code]string{
+	key.CodeDeleteBackspace: "backspace",
+	key.CodeDeleteForward:   "delete",
+	key.CodeLeftArrow:       "left",
+	key.CodeRightArrow:      "right",
+	key.CodeUpArrow:         "up",
+	key.CodeDownArrow:       "down",
+	key.CodeHome:            "home",
+	key.CodeEnd:             "end",
+}
+
+// typed turns a key press from the soft keyboard into a Typed message.
+// Android repeats the press while a key is held, so holding Backspace
+// keeps deleting on the PC too.
+func typed(e key.Event) (proto.Message, bool) {
+	if e.Direction != key.DirPress {
+		return proto.Message{}, false
+	}
+	if e.Rune >= ' ' || e.Rune == '\n' || e.Rune == '\t' {
+		return proto.Message{Type: proto.Typed, Text: string(e.Rune)}, true
+	}
+	if name, ok := editingKeys[e.Code]; ok {
+		return proto.Message{Type: proto.Typed, Key: name}, true
+	}
+	return proto.Message{}, false
+}
+
+// imeText is what the soft keyboard entered other than by key events:
+// text it committed, such as a word from swipe or voice typing, or a
+// number of characters it deleted around the cursor.
+type imeText struct {
+	text          string
+	before, after int
+}
+
+// keyboardText carries imeText from Android's UI thread to the event loop.
+var keyboardText = make(chan imeText, 64)
+
+// typedText turns what the soft keyboard entered into Typed messages,
+// deleting before typing as the keyboard does when it replaces a word.
+func typedText(t imeText) []proto.Message {
+	var msgs []proto.Message
+	for range t.before {
+		msgs = append(msgs, proto.Message{Type: proto.Typed, Key: "backspace"})
+	}
+	for range t.after {
+		msgs = append(msgs, proto.Message{Type: proto.Typed, Key: "delete"})
+	}
+	if t.text != "" {
+		msgs = append(msgs, proto.Message{Type: proto.Typed, Text: t.text})
+	}
+	return msgs
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+	"golang.org/x/mobile/app"
+)
+
+// linkState is sent into the event loop when the host connects or goes away.
+type linkState struct {
+	connected bool
+}
+
+// hostLink serves the host's connection. Only one host is connected at a
+// time; a new connection replaces the old one once it has proved it is
+// the host that launched the app. Messages from the host, other than
+// heartbeats, are delivered to the event loop with a.Send.
+type hostLink struct {
+	a    app.App
+	mu   sync.Mutex
+	c    net.Conn
+	conn *proto.Conn
+}
+
+func (l *hostLink) serve() {
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", proto.DevicePort))
+	if err != nil {
+		return
+	}
+	go l.heartbeat()
+	go l.reportBattery()
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go l.accept(c)
+	}
+}
+
+// accept takes c over as the host's connection if its first message is a
+// Hello with the token the app was launched with, and drops it otherwise.
+func (l *hostLink) accept(c net.Conn) {
+	conn := proto.NewConn(c)
+	c.SetReadDeadline(time.Now().Add(proto.LinkTimeout))
+	m, err := conn.Receive()
+	token := launchToken()
+	if err != nil || m.Type != proto.Hello || token == "" ||
+		subtle.ConstantTimeCompare([]byte(m.Token), []byte(token)) != 1 {
+		c.Close()
+		return
+	}
+
+	l.mu.Lock()
+	if l.c != nil {
+		l.c.Close()
+	}
+	l.c, l.conn = c, conn
+	l.mu.Unlock()
+	l.a.Send(linkState{connected: true})
+	l.read(c, conn)
+}
+
+func (l *hostLink) read(c net.Conn, conn *proto.Conn) {
+	for {
+		c.SetReadDeadline(time.Now().Add(proto.LinkTimeout))
+		m, err := conn.Receive()
+		if err != nil {
+			l.drop(c)
+			return
+		}
+		if m.Type != proto.Heartbeat {
+			l.a.Send(m)
+		}
+	}
+}
+
+// drop closes c and, if it is still the current connection, reports the
+// host as gone.
+func (l *hostLink) drop(c net.Conn) {
+	c.Close()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.c == c {
+		l.c, l.conn = nil, nil
+		l.a.Send(linkState{connected: false})
+	}
+}
+
+func (l *hostLink) heartbeat() {
+	for range time.Tick(proto.HeartbeatInterval) {
+		l.send(proto.Message{Type: proto.Heartbeat})
+	}
+}
+
+// send delivers a message to the host if one is connected.
+func (l *hostLink) send(m proto.Message) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return
+	}
+	l.c.SetWriteDeadline(time.Now().Add(proto.LinkTimeout))
+	if l.conn.Send(m) != nil {
+		// The reader sees the closed connection and reports it.
+		l.c.Close()
+	}
+}
+
+// batteryInterval is how often the battery is reported to the host.
+const batteryInterval = 30 * time.Second
+
+func (l *hostLink) reportBattery() {
+	for {
+		l.sendBattery()
+		time.Sleep(batteryInterval)
+	}
+}
+
+func (l *hostLink) sendBattery() {
+	if level, charging, err := batteryStatus(); err == nil {
+		l.send(proto.Message{Type: proto.Battery, Level: level, Charging: charging})
+	}
+}
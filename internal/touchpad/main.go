@@ -3,42 +3,280 @@ package main
 import (
 	"time"
 
+	"github.com/mmngadi/touchpad-tool/internal/layout"
+	"github.com/mmngadi/touchpad-tool/internal/proto"
 	"golang.org/x/mobile/app"
 	"golang.org/x/mobile/event/key"
 	"golang.org/x/mobile/event/lifecycle"
 	"golang.org/x/mobile/event/paint"
+	"golang.org/x/mobile/event/size"
+	"golang.org/x/mobile/event/touch"
 	"golang.org/x/mobile/gl"
 )
 
 func main() {
 	app.Main(func(a app.App) {
 		var glctx gl.Context
-		var lastBackPress time.Time
-		var flashUntil time.Time // Used for visual feedback on first press
+		var sz size.Event
+		var flashUntil time.Time // Used for visual feedback on a Back press that did not exit
+
+		// How Back and the volume keys behave is set by the host; until then Back needs a double press
+		keys := phoneKeys{exit: proto.ExitDouble}
+		var forwardVolume bool
+
+		// Low power mode only paints when the user would otherwise miss something
+		var lowPower, redraw bool
+
+		// Everything shown besides the pause button comes from the host; until it connects we just stay black
+		var surface layout.Layout
+		var connected bool
+		mode := proto.ModePointer
+		stage := proto.StageBackground
+		display := proto.DisplayNormal
+
+		// Fingers currently on the screen, drawn as feedback
+		touches := make(map[touch.Sequence]touch.Event)
+
+		// Touches arrive far faster than frames, so only one paint is queued at a time
+		var paintQueued bool
+		repaint := func() {
+			if !paintQueued {
+				paintQueued = true
+				a.Send(paint.Event{})
+			}
+		}
+
+		link := &hostLink{a: a}
+		go link.serve()
+
+		// With the wake lock option the app keeps the screen on itself, but only while in front
+		var wakeLock, wakeLockHeld bool
+		updateWakeLock := func() {
+			want := wakeLock && stage == proto.StageForeground
+			if want != wakeLockHeld && setWakeLock(want) == nil {
+				wakeLockHeld = want
+			}
+		}
+		quit := func() {
+			link.send(proto.Message{Type: proto.Lifecycle, Stage: proto.StageExit})
+			if wakeLockHeld {
+				setWakeLock(false)
+			}
+		}
+
+		// Clipboard sync is off until the host turns it on
+		var clip clipboardSync
+		go func() {
+			for range time.Tick(clipboardPoll) {
+				a.Send(clipboardTick{})
+			}
+		}()
+
+		// With capture on, the host takes its input from our touch events instead of getevent
+		var capture bool
+		var batch touchBatch
+
+		// Pausing hands the phone back to the user without ending the session
+		var paused bool
+		var pauseSeq touch.Sequence = -1
+
+		// While typing, the soft keyboard is open and what is typed goes to the PC
+		var typing bool
+		var keyboardSeq touch.Sequence = -1
+		setTyping := func(on bool) {
+			if on != typing && showKeyboard(on) == nil {
+				typing = on
+				redraw = true
+				repaint()
+			}
+		}
+		// Text the keyboard enters a word at a time comes from the UI thread
+		go func() {
+			for t := range keyboardText {
+				a.Send(t)
+			}
+		}()
 
 		for e := range a.Events() {
 			switch e := a.Filter(e).(type) {
 			case lifecycle.Event:
 				glctx, _ = e.DrawContext.(gl.Context)
+				if e.Crosses(lifecycle.StageFocused) != lifecycle.CrossNone {
+					stage = proto.StageBackground
+					if e.To >= lifecycle.StageFocused {
+						stage = proto.StageForeground
+					}
+					link.send(proto.Message{Type: proto.Lifecycle, Stage: stage})
+					updateWakeLock()
+					// Android closes the keyboard along with the app
+					if stage == proto.StageBackground && typing {
+						typing = false
+					}
+				}
+
+			case linkState:
+				connected = e.connected
+				if connected {
+					link.send(proto.Message{Type: proto.Lifecycle, Stage: stage})
+					link.send(proto.Message{Type: proto.Surface, Width: sz.WidthPx, Height: sz.HeightPx})
+					go link.sendBattery()
+				}
+				repaint()
+
+			case proto.Message:
+				switch e.Type {
+				case proto.Config:
+					if e.Layout != nil {
+						surface = *e.Layout
+					}
+					capture = e.Capture
+					forwardVolume = e.ForwardVolume
+					lowPower, redraw = e.LowPower, true
+					wakeLock = e.WakeLock
+					updateWakeLock()
+					clip.setLimit(e.Clipboard)
+					if e.Exit != "" {
+						keys.exit = e.Exit
+					}
+					if e.Display != "" {
+						display = e.Display
+						brightness = displayBrightness(display)
+					}
+				case proto.Feedback:
+					mode = e.Mode
+					// The PC can pause too, e.g. through its control API
+					paused = mode == proto.ModePaused
+				case proto.Haptic:
+					go vibrate(e.Pattern, e.Amplitude)
+					continue
+				case proto.Clipboard:
+					clip.set(e.Text)
+					continue
+				}
+				repaint()
+
+			case size.Event:
+				sz = e
+				link.send(proto.Message{Type: proto.Surface, Width: sz.WidthPx, Height: sz.HeightPx})
+
+			case clipboardTick:
+				if stage == proto.StageForeground {
+					clip.check(link)
+				}
+
+			case flushTouches:
+				batch.scheduled = false
+				batch.flush(link)
+
+			case touch.Event:
+				if capture {
+					batch.add(a, link, e)
+				}
+				if e.Type == touch.TypeEnd {
+					delete(touches, e.Sequence)
+				} else {
+					touches[e.Sequence] = e
+				}
+				if display != proto.DisplayOff && !lowPower {
+					repaint()
+				}
+
+				// The pause and keyboard buttons are handled here; the host ignores touches that land on them
+				x, y := float64(e.X)/float64(sz.WidthPx), float64(e.Y)/float64(sz.HeightPx)
+				switch e.Type {
+				case touch.TypeBegin:
+					if layout.PauseButton.Contains(x, y) {
+						pauseSeq = e.Sequence
+					}
+					if layout.KeyboardButton.Contains(x, y) {
+						keyboardSeq = e.Sequence
+					}
+				case touch.TypeEnd:
+					if e.Sequence == keyboardSeq && layout.KeyboardButton.Contains(x, y) {
+						setTyping(!typing)
+					}
+					if e.Sequence == keyboardSeq {
+						keyboardSeq = -1
+					}
+					if e.Sequence == pauseSeq && layout.PauseButton.Contains(x, y) {
+						paused = !paused
+						if paused {
+							link.send(proto.Message{Type: proto.Pause})
+						} else {
+							link.send(proto.Message{Type: proto.Resume})
+						}
+						redraw = true
+						repaint()
+					}
+					if e.Sequence == pauseSeq {
+						pauseSeq = -1
+					}
+				}
 
 			case key.Event:
+				if e.Code == key.CodeVolumeUp || e.Code == key.CodeVolumeDown {
+					if e.Direction == key.DirNone {
+						continue
+					}
+					down := e.Direction == key.DirPress
+					changed, exit := keys.volume(e.Code, down)
+					if exit {
+						quit()
+						return
+					}
+					if changed && forwardVolume {
+						name := proto.KeyVolumeDown
+						if e.Code == key.CodeVolumeUp {
+							name = proto.KeyVolumeUp
+						}
+						link.send(proto.Message{Type: proto.Key, Key: name, Down: down})
+					}
+					continue
+				}
+
 				// Raw Android KeyCode for Back is 4
 				// We check the e.Code or the raw event if available
-				if (e.Code == 4 || e.Code == key.CodeEscape) && e.Direction == key.DirRelease {
+				if e.Code == 4 || e.Code == key.CodeEscape {
+					// Back closes the keyboard first, and does not count towards exiting
+					if typing {
+						if e.Direction == key.DirRelease {
+							setTyping(false)
+						}
+						continue
+					}
 					now := time.Now()
-					if now.Sub(lastBackPress) < 2*time.Second {
-						return // Double-tap detected: Exit
+					if keys.back(e.Direction, now) {
+						quit()
+						return
+					}
+					if e.Direction == key.DirRelease && keys.exit != proto.ExitDisabled && !lowPower {
+						flashUntil = now.Add(150 * time.Millisecond)
+						repaint()
 					}
-					lastBackPress = now
-					flashUntil = now.Add(150 * time.Millisecond)
-					a.Send(paint.Event{})
 					continue // BLOCK the event from reaching the OS
 				}
 
+				if m, ok := typed(e); ok && typing {
+					link.send(m)
+				}
+
+			case imeText:
+				if typing {
+					for _, m := range typedText(e) {
+						link.send(m)
+					}
+				}
+
 			case paint.Event:
+				paintQueued = false
 				if glctx == nil {
 					continue
 				}
+				// Past the first frame, low power mode only draws a new surface or a change the user made
+				if lowPower && !redraw && !e.External {
+					continue
+				}
+				redraw = false
 
 				// Visual Feedback: Flash gray if we just pressed back, otherwise black
 				if time.Now().Before(flashUntil) {
@@ -48,11 +286,19 @@ func main() {
 				}
 
 				glctx.Clear(gl.COLOR_BUFFER_BIT)
+				drawZones(glctx, sz, surface.Zones)
+				drawPauseButton(glctx, sz, paused)
+				drawKeyboardButton(glctx, sz, typing)
+				drawStatus(glctx, sz, connected)
+				if display != proto.DisplayOff && !lowPower {
+					drawMode(glctx, sz, mode)
+					drawContacts(glctx, sz, touches, mode)
+				}
 				a.Publish()
 
 				// If we are flashing, keep repainting until the flash duration ends
 				if time.Now().Before(flashUntil) {
-					a.Send(paint.Event{})
+					repaint()
 				}
 			}
 		}
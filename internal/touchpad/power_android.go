@@ -0,0 +1,105 @@
+//go:build android
+
+package main
+
+/*
+#include "jni_android.h"
+
+static jobject wake_lock;
+
+// set_wake_lock acquires or releases a screen wake lock. The lock is dim
+// rather than bright, and unlike `svc power stayon` it goes away with the
+// app. It returns 0 on success.
+static int set_wake_lock(uintptr_t jni_env, uintptr_t jctx, int on) {
+	JNIEnv *env = (JNIEnv *)jni_env;
+	if ((*env)->PushLocalFrame(env, 16) < 0) {
+		return -1;
+	}
+	if (wake_lock == NULL) {
+		jobject pm = system_service(env, (jobject)jctx, "power");
+		if (clear_exception(env) || pm == NULL) {
+			(*env)->PopLocalFrame(env, NULL);
+			return -1;
+		}
+		jclass pmClass = (*env)->GetObjectClass(env, pm);
+		jmethodID newWakeLock = (*env)->GetMethodID(env, pmClass, "newWakeLock", "(ILjava/lang/String;)Landroid/os/PowerManager$WakeLock;");
+		// SCREEN_DIM_WAKE_LOCK | ON_AFTER_RELEASE
+		jobject wl = (*env)->CallObjectMethod(env, pm, newWakeLock, 0x6 | 0x20000000, (*env)->NewStringUTF(env, "touchpad:session"));
+		if (clear_exception(env) || wl == NULL) {
+			(*env)->PopLocalFrame(env, NULL);
+			return -1;
+		}
+		jclass wlClass = (*env)->GetObjectClass(env, wl);
+		jmethodID setCounted = (*env)->GetMethodID(env, wlClass, "setReferenceCounted", "(Z)V");
+		(*env)->CallVoidMethod(env, wl, setCounted, JNI_FALSE);
+		wake_lock = (*env)->NewGlobalRef(env, wl);
+	}
+	jclass wlClass = (*env)->GetObjectClass(env, wake_lock);
+	jmethodID m = (*env)->GetMethodID(env, wlClass, on ? "acquire" : "release", "()V");
+	(*env)->CallVoidMethod(env, wake_lock, m);
+	int ret = clear_exception(env);
+	(*env)->PopLocalFrame(env, NULL);
+	return ret;
+}
+
+// battery_status reads the charge in percent and whether the phone is
+// charging. It returns 0 on success.
+static int battery_status(uintptr_t jni_env, uintptr_t jctx, int *level, int *charging) {
+	JNIEnv *env = (JNIEnv *)jni_env;
+	if ((*env)->PushLocalFrame(env, 16) < 0) {
+		return -1;
+	}
+	jobject bm = system_service(env, (jobject)jctx, "batterymanager");
+	if (clear_exception(env) || bm == NULL) {
+		(*env)->PopLocalFrame(env, NULL);
+		return -1;
+	}
+	jclass bmClass = (*env)->GetObjectClass(env, bm);
+	jmethodID getInt = (*env)->GetMethodID(env, bmClass, "getIntProperty", "(I)I");
+	*level = (*env)->CallIntMethod(env, bm, getInt, 4); // BATTERY_PROPERTY_CAPACITY
+	jmethodID isCharging = (*env)->GetMethodID(env, bmClass, "isCharging", "()Z");
+	if (isCharging != NULL) {
+		*charging = (*env)->CallBooleanMethod(env, bm, isCharging);
+	} else {
+		// Before API 23; report not charging rather than nothing.
+		(*env)->ExceptionClear(env);
+	}
+	int ret = clear_exception(env);
+	(*env)->PopLocalFrame(env, NULL);
+	return ret;
+}
+*/
+import "C"
+
+import (
+	"errors"
+
+	"golang.org/x/mobile/app"
+)
+
+// setWakeLock holds or lets go of the app's screen wake lock.
+func setWakeLock(on bool) error {
+	var flag C.int
+	if on {
+		flag = 1
+	}
+	return app.RunOnJVM(func(vm, env, ctx uintptr) error {
+		if C.set_wake_lock(C.uintptr_t(env), C.uintptr_t(ctx), flag) != 0 {
+			return errors.New("wake lock unavailable")
+		}
+		return nil
+	})
+}
+
+// batteryStatus returns the battery charge in percent and whether the
+// phone is charging.
+func batteryStatus() (level int, charging bool, err error) {
+	var l, c C.int
+	err = app.RunOnJVM(func(vm, env, ctx uintptr) error {
+		if C.battery_status(C.uintptr_t(env), C.uintptr_t(ctx), &l, &c) != 0 {
+			return errors.New("battery status unavailable")
+		}
+		return nil
+	})
+	return int(l), c != 0, err
+}
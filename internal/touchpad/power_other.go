@@ -0,0 +1,15 @@
+//go:build !android
+
+package main
+
+import "errors"
+
+// setWakeLock does nothing off Android.
+func setWakeLock(on bool) error {
+	return nil
+}
+
+// batteryStatus is only known on Android.
+func batteryStatus() (level int, charging bool, err error) {
+	return 0, false, errors.New("no battery")
+}
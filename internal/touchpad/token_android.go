@@ -0,0 +1,64 @@
+//go:build android
+
+package main
+
+/*
+#include <stdlib.h>
+#include <string.h>
+#include "jni_android.h"
+
+// intent_extra returns getIntent().getStringExtra(name) of the activity as
+// a C string the caller must free, or NULL if it is not set.
+static char *intent_extra(uintptr_t jni_env, uintptr_t jctx, const char *name) {
+	JNIEnv *env = (JNIEnv *)jni_env;
+	jobject ctx = (jobject)jctx;
+	if ((*env)->PushLocalFrame(env, 8) < 0) {
+		return NULL;
+	}
+	jclass actClass = (*env)->GetObjectClass(env, ctx);
+	jmethodID getIntent = (*env)->GetMethodID(env, actClass, "getIntent", "()Landroid/content/Intent;");
+	jobject intent = (*env)->CallObjectMethod(env, ctx, getIntent);
+	if (clear_exception(env) || intent == NULL) {
+		(*env)->PopLocalFrame(env, NULL);
+		return NULL;
+	}
+	jclass intentClass = (*env)->GetObjectClass(env, intent);
+	jmethodID getExtra = (*env)->GetMethodID(env, intentClass, "getStringExtra", "(Ljava/lang/String;)Ljava/lang/String;");
+	jstring value = (jstring)(*env)->CallObjectMethod(env, intent, getExtra, (*env)->NewStringUTF(env, name));
+	if (clear_exception(env) || value == NULL) {
+		(*env)->PopLocalFrame(env, NULL);
+		return NULL;
+	}
+	const char *chars = (*env)->GetStringUTFChars(env, value, NULL);
+	char *out = chars == NULL ? NULL : strdup(chars);
+	if (chars != NULL) {
+		(*env)->ReleaseStringUTFChars(env, value, chars);
+	}
+	(*env)->PopLocalFrame(env, NULL);
+	return out;
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+	"golang.org/x/mobile/app"
+)
+
+// launchToken returns the session token the host started the app with, or
+// "" if the app was started some other way.
+func launchToken() string {
+	var token string
+	app.RunOnJVM(func(vm, env, ctx uintptr) error {
+		name := C.CString(proto.TokenExtra)
+		defer C.free(unsafe.Pointer(name))
+		if s := C.intent_extra(C.uintptr_t(env), C.uintptr_t(ctx), name); s != nil {
+			token = C.GoString(s)
+			C.free(unsafe.Pointer(s))
+		}
+		return nil
+	})
+	return token
+}
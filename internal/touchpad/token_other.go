@@ -0,0 +1,8 @@
+//go:build !android
+
+package main
+
+// launchToken returns "" off Android, where no host launches the app.
+func launchToken() string {
+	return ""
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/mmngadi/touchpad-tool/internal/layout"
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+)
+
+// appPaused is set while the user has paused the touchpad, from the phone
+// or the control API. Input is dropped and the kiosk watchdog leaves the
+// phone alone.
+var appPaused atomic.Bool
+
+// appLink is the host end of the channel to the app. The app's heartbeats
+// and lifecycle messages are how the host knows it is alive and in front.
+type appLink struct {
+	lastSeen  atomic.Int64 // UnixNano of the last message, 0 until the first
+	connected atomic.Bool
+
+	mu   sync.Mutex
+	out  chan proto.Message // queue of the current connection, nil between them
+	mode string             // last mode reported by the engine
+}
+
+var link = &appLink{mode: proto.ModePointer}
+
+// appToken is the session token the app is launched with and that every
+// connection to it starts with. It is set once before the app is started.
+var appToken string
+
+// Send queues m for the app without blocking. Messages are dropped while
+// the app is not connected or not keeping up; every connection starts
+// with a fresh Config and mode, so nothing lasting is lost.
+func (l *appLink) Send(m proto.Message) {
+	l.mu.Lock()
+	out := l.out
+	l.mu.Unlock()
+	select {
+	case out <- m:
+	default:
+	}
+}
+
+// SetMode records the engine's mode and sends it to the app.
+func (l *appLink) SetMode(mode string) {
+	l.mu.Lock()
+	l.mode = mode
+	l.mu.Unlock()
+	l.Send(proto.Message{Type: proto.Feedback, Mode: mode})
+}
+
+// forwardApp forwards a free local port to the app's socket and returns it.
+func forwardApp() (int, error) {
+	out, err := exec.Command(adbPath, "forward", "tcp:0", "tcp:"+strconv.Itoa(proto.DevicePort)).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+// startAppLink connects to the app through adb forward and keeps the
+// connection up until exit. The app may not be listening yet, or may be
+// restarted by the watchdog, so connecting is retried. Once the app has
+// been heard from, losing it for longer than proto.LinkTimeout ends the
+// session, as does the user closing the app; until then watchAppByADB
+// stands in. It returns the forwarded port, or 0 if forwarding failed.
+func startAppLink(engine *gestureEngine, s linkSettings, sigChan chan os.Signal) int {
+	port, err := forwardApp()
+	if err != nil {
+		fmt.Printf("[-] Could not forward the app port: %v\n", err)
+		return 0
+	}
+	go func() {
+		addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+		for !isExiting.Load() {
+			if c, err := net.Dial("tcp", addr); err == nil {
+				if link.serve(c, engine, s) {
+					stopSession(sigChan, "\n[!] App closed on the phone. Exiting...")
+					return
+				}
+			}
+			if link.lost() && !appPaused.Load() {
+				stopSession(sigChan, "\n[!] App stopped responding. Exiting...")
+				return
+			}
+			time.Sleep(time.Second)
+		}
+	}()
+	return port
+}
+
+// appLinkGrace is how long the app has to connect over the link before
+// the host watches it through adb instead.
+const appLinkGrace = 5 * time.Second
+
+// watchAppByADB follows the app's focus and process through adb for as
+// long as it has never connected over the link, e.g. because adb forward
+// failed or an older APK without the link is installed. Without it the
+// host would take the app to be in front, and alive, forever.
+func watchAppByADB(sigChan chan os.Signal) {
+	time.Sleep(appLinkGrace)
+	warned := false
+	for !isExiting.Load() && link.lastSeen.Load() == 0 {
+		if !warned {
+			fmt.Println("[!] App has not connected, watching it through adb instead")
+			warned = true
+		}
+		out, err := exec.Command(adbPath, "shell", "dumpsys window displays | grep mCurrentFocus").Output()
+		if err == nil && link.lastSeen.Load() == 0 {
+			appInForeground.Store(strings.Contains(string(out), pkgName))
+		}
+		out, _ = exec.Command(adbPath, "shell", "pidof", pkgName).Output()
+		if len(strings.TrimSpace(string(out))) == 0 && link.lastSeen.Load() == 0 {
+			stopSession(sigChan, "\n[!] App process closed. Exiting...")
+			return
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func stopSession(sigChan chan os.Signal, msg string) {
+	if isExiting.Load() {
+		return
+	}
+	fmt.Println(msg)
+	select {
+	case sigChan <- syscall.SIGTERM:
+	default:
+	}
+}
+
+// lost reports whether the app was heard from once but not recently.
+// A paused app may be frozen by Android in the background, so callers
+// do not count that as the app going away.
+func (l *appLink) lost() bool {
+	seen := l.lastSeen.Load()
+	return seen != 0 && time.Since(time.Unix(0, seen)) > proto.LinkTimeout
+}
+
+// linkSettings are the parts of cfg that the link and the pause and resume
+// paths use. None of them can be changed at runtime; they are copied before
+// the engine starts because cfg belongs to the engine goroutine from then on.
+type linkSettings struct {
+	zones         []layout.Zone
+	capture       bool // input comes from the app, see inputApp
+	display, back string
+	forwardVolume bool // a volume key is bound
+	power         PowerConfig
+	clipboard     ClipboardConfig
+}
+
+func newLinkSettings() linkSettings {
+	return linkSettings{
+		zones:   cfg.Zones,
+		capture: cfg.Input == inputApp,
+		display: cfg.Display,
+		back:    cfg.Back,
+		// Volume keys keep working as volume keys unless bound.
+		forwardVolume: anyBinding("volume-"),
+		power:         cfg.Power,
+		clipboard:     cfg.Clipboard,
+	}
+}
+
+// config is the Config message every connection starts with.
+func (s linkSettings) config() proto.Message {
+	return proto.Message{
+		Type:          proto.Config,
+		Layout:        &layout.Layout{Zones: s.zones},
+		Capture:       s.capture,
+		Display:       s.display,
+		Exit:          s.back,
+		LowPower:      s.power.LowPower,
+		WakeLock:      s.power.WakeLock,
+		Clipboard:     clipboardLimit(s.clipboard),
+		ForwardVolume: s.forwardVolume,
+	}
+}
+
+// serve runs one connection until it fails. It reports true if the app
+// said the user closed it.
+func (l *appLink) serve(c net.Conn, engine *gestureEngine, s linkSettings) bool {
+	defer c.Close()
+	conn := proto.NewConn(c)
+	// adb accepts the connection even when the app is not listening, so
+	// the app only counts as connected once it has said something.
+	defer l.connected.Store(false)
+	// A phone key held when the app goes away never sends its release.
+	defer engine.Do(engine.releasePhoneKeys)
+
+	c.SetWriteDeadline(time.Now().Add(proto.LinkTimeout))
+	if conn.Send(proto.Message{Type: proto.Hello, Token: appToken}) != nil {
+		return false
+	}
+	// Each connection gets its own queue, which starts with a fresh
+	// Config and mode; anything queued for an earlier one is stale.
+	out := make(chan proto.Message, 32)
+	out <- s.config()
+	l.mu.Lock()
+	out <- proto.Message{Type: proto.Feedback, Mode: l.mode}
+	l.out = out
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		if l.out == out {
+			l.out = nil
+		}
+		l.mu.Unlock()
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+	go l.write(c, conn, out, done)
+
+	for {
+		c.SetReadDeadline(time.Now().Add(proto.LinkTimeout))
+		m, err := conn.Receive()
+		if err != nil {
+			return false
+		}
+		l.lastSeen.Store(time.Now().UnixNano())
+		l.connected.Store(true)
+		switch m.Type {
+		case proto.Lifecycle:
+			if m.Stage == proto.StageExit {
+				return true
+			}
+			front := m.Stage == proto.StageForeground
+			appInForeground.Store(front)
+			if !front {
+				engine.Do(engine.releasePhoneKeys)
+			}
+		case proto.Surface, proto.Touch:
+			if !s.capture {
+				break
+			}
+			select {
+			case appFrames <- m:
+			default:
+			}
+		case proto.Battery:
+			noteBattery(m.Level, m.Charging)
+		case proto.Clipboard:
+			receiveClipboard(s.clipboard, m.Text)
+		case proto.Key:
+			engine.Do(func() { engine.phoneKey(m.Key, m.Down) })
+		case proto.Typed:
+			engine.Do(func() { engine.typed(m.Text, m.Key) })
+		case proto.Pause:
+			pauseSession(engine, "the phone")
+		case proto.Resume:
+			resumeSession(engine, s, "the phone")
+		}
+	}
+}
+
+// write sends messages queued on out and heartbeats until done is closed.
+// A failed write closes the connection, which ends serve's read loop.
+func (l *appLink) write(c net.Conn, conn *proto.Conn, out <-chan proto.Message, done <-chan struct{}) {
+	ticker := time.NewTicker(proto.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		var m proto.Message
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			m = proto.Message{Type: proto.Heartbeat}
+		case m = <-out:
+		}
+		c.SetWriteDeadline(time.Now().Add(proto.LinkTimeout))
+		if err := conn.Send(m); err != nil {
+			c.Close()
+			return
+		}
+	}
+}
+
+// sessionMu keeps a pause and a resume, which the phone and the control
+// API can ask for at the same time, from interleaving their adb calls.
+var sessionMu sync.Mutex
+
+// pauseSession stops input and gives the phone back its normal behaviour
+// until the user resumes. from says who asked, for the log. It must not
+// run on the engine goroutine.
+func pauseSession(engine *gestureEngine, from string) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if appPaused.Swap(true) {
+		return
+	}
+	fmt.Printf("[*] Paused from %s.\n", from)
+	engine.Do(func() { engine.SetPaused(true) })
+	runADB("shell", "settings", "put", "system", "accelerometer_rotation", "1")
+	runADB("shell", "settings", "put", "global", "policy_control", "null")
+	restoreBrightness()
+}
+
+func resumeSession(engine *gestureEngine, s linkSettings, from string) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if !appPaused.Swap(false) {
+		return
+	}
+	fmt.Printf("[*] Resumed from %s.\n", from)
+	setupEnvironment(s.power)
+	launchApp()
+	engine.Do(func() { engine.SetPaused(false) })
+}
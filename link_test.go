@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+)
+
+func TestAppLinkServe(t *testing.T) {
+	e := startEngine(t).e
+	oldToken := appToken
+	appToken = "0123456789abcdef"
+	t.Cleanup(func() { appToken = oldToken })
+	l := &appLink{mode: proto.ModeScroll}
+	cfg.Display = proto.DisplayDim
+	settings := newLinkSettings()
+
+	// Nothing is connected: sending must neither block nor queue.
+	for range 100 {
+		l.Send(proto.Message{Type: proto.Haptic})
+	}
+
+	host, phone := net.Pipe()
+	defer phone.Close()
+	served := make(chan bool)
+	go func() { served <- l.serve(host, e, settings) }()
+
+	app := proto.NewConn(phone)
+	phone.SetDeadline(time.Now().Add(5 * time.Second))
+	want := []proto.Message{
+		{Type: proto.Hello, Token: "0123456789abcdef"},
+		{Type: proto.Config, Display: proto.DisplayDim},
+		{Type: proto.Feedback, Mode: proto.ModeScroll},
+	}
+	for _, w := range want {
+		m, err := app.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Type != w.Type || m.Token != w.Token || m.Mode != w.Mode || m.Display != w.Display {
+			t.Fatalf("got %+v, want %+v", m, w)
+		}
+	}
+
+	// The app is not reading: the queue fills up and the rest is dropped
+	// instead of blocking the sender.
+	sent := make(chan struct{})
+	go func() {
+		for range 1000 {
+			l.Send(proto.Message{Type: proto.Haptic})
+		}
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send blocked")
+	}
+
+	if err := app.Send(proto.Message{Type: proto.Lifecycle, Stage: proto.StageExit}); err != nil {
+		t.Fatal(err)
+	}
+	// Drain what was queued so the writer can finish.
+	go func() {
+		for {
+			if _, err := app.Receive(); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case closed := <-served:
+		if !closed {
+			t.Error("serve did not report the app closing")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return")
+	}
+	if l.out != nil {
+		t.Error("the connection's queue outlived it")
+	}
+}
+
+// TestAppLinkWhileTuning retunes the engine while the link handles
+// messages from the app, which the race detector checks for shared state.
+func TestAppLinkWhileTuning(t *testing.T) {
+	r := startEngine(t)
+	settings := newLinkSettings()
+	l := &appLink{mode: proto.ModePointer}
+
+	host, phone := net.Pipe()
+	defer phone.Close()
+	served := make(chan bool)
+	go func() { served <- l.serve(host, r.e, settings) }()
+	app := proto.NewConn(phone)
+	go func() {
+		for {
+			if _, err := app.Receive(); err != nil {
+				return
+			}
+		}
+	}()
+
+	tuned := make(chan struct{})
+	go func() {
+		defer close(tuned)
+		for i := range 50 {
+			patch := map[string]json.RawMessage{"sensitivity": json.RawMessage(fmt.Sprint(1 + i%3))}
+			r.e.Do(func() {
+				if err := applyTuning(patch); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}()
+	for range 50 {
+		msgs := []proto.Message{
+			{Type: proto.Touch, Seq: 1},
+			{Type: proto.Clipboard, Text: "copied"},
+			{Type: proto.Lifecycle, Stage: proto.StageForeground},
+		}
+		for _, m := range msgs {
+			if err := app.Send(m); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	<-tuned
+	if err := app.Send(proto.Message{Type: proto.Lifecycle, Stage: proto.StageExit}); err != nil {
+		t.Fatal(err)
+	}
+	if !<-served {
+		t.Error("serve did not report the app closing")
+	}
+}
+
+func TestAppLinkReleasesPhoneKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		end  func(app *proto.Conn, phone net.Conn)
+	}{
+		{"background", func(app *proto.Conn, phone net.Conn) {
+			app.Send(proto.Message{Type: proto.Lifecycle, Stage: proto.StageBackground})
+		}},
+		{"link drop", func(app *proto.Conn, phone net.Conn) {
+			phone.Close()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := startEngine(t)
+			cfg.Bindings = map[string]Action{proto.KeyVolumeUp: {Button: "left"}}
+			settings := newLinkSettings()
+			l := &appLink{mode: proto.ModePointer}
+
+			host, phone := net.Pipe()
+			defer phone.Close()
+			served := make(chan bool, 1)
+			go func() { served <- l.serve(host, r.e, settings) }()
+			app := proto.NewConn(phone)
+			go func() {
+				for {
+					if _, err := app.Receive(); err != nil {
+						return
+					}
+				}
+			}()
+
+			app.Send(proto.Message{Type: proto.Lifecycle, Stage: proto.StageForeground})
+			app.Send(proto.Message{Type: proto.Key, Key: proto.KeyVolumeUp, Down: true})
+			app.Send(proto.Message{Type: proto.Heartbeat})
+			r.expect("left down")
+
+			tt.end(app, phone)
+			deadline := time.Now().Add(5 * time.Second)
+			for len(r.f.Events()) < 2 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			r.expect("left down", "left up")
+		})
+	}
+}
@@ -1,21 +1,20 @@
 package main
 
 import (
-	"bufio"
 	_ "embed"
 	"fmt"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"strconv"
-	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/mmngadi/touchpad-tool/internal/drivers"
+	"github.com/mmngadi/touchpad-tool/internal/proto"
 )
 
 //go:embed internal/touchpad-release.apk
@@ -25,20 +24,16 @@ const (
 	pkgName      = "org.golang.todo.touchpad"
 	activityName = "org.golang.app.GoNativeActivity"
 	touchDevice  = "/dev/input/event4"
-
-	sensitivity      = 3.2
-	scrollSens       = 120
-	tapTimeout       = 200 * time.Millisecond
-	doubleTapTimeout = 250 * time.Millisecond
-	longPressTimeout = 600 * time.Millisecond
 )
 
 var (
-	driver          drivers.MouseDriver
+	driver          drivers.Driver
+	cfg             Config
 	adbPath         = "adb"
-	appInForeground = true
-	isExiting       = false
-	inputCmd        *exec.Cmd
+	appInForeground atomic.Bool
+	isExiting       atomic.Bool
+	input           inputSource
+	inputDone       = make(chan struct{})
 )
 
 func init() {
@@ -51,7 +46,25 @@ func init() {
 }
 
 func main() {
-	driver = drivers.InitDriver()
+	if len(os.Args) > 1 && os.Args[1] == "ctl" {
+		os.Exit(runCtl(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "udev" {
+		os.Exit(runUdev(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "setup-linux" {
+		os.Exit(runSetupLinux(os.Args[2:]))
+	}
+
+	cfg = loadConfig()
+	batteryWarning.Store(int32(cfg.Power.BatteryWarning))
+	var err error
+	driver, err = drivers.InitDriver(cfg.Device.identity(phoneModel()))
+	if err != nil {
+		fmt.Printf("[-] Cannot create the virtual input device: %v\n", err)
+		os.Exit(1)
+	}
+	appInForeground.Store(true)
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -64,56 +77,68 @@ func main() {
 
 	fmt.Printf("[*] Touchpad Tool Active: Focused Watchdog Mode\n")
 
-	setupEnvironment()
+	setupEnvironment(cfg.Power)
 
+	appToken, err = newToken()
+	if err != nil {
+		fmt.Printf("[-] Cannot create a session token: %v\n", err)
+		os.Exit(1)
+	}
 	fmt.Println("[*] Installing and Launching App...")
 	_ = exec.Command(adbPath, "install", "-r", tmpAPK).Run()
+	// An app left running by an earlier session still holds its token.
+	runADB("shell", "am", "force-stop", pkgName)
 	launchApp()
 
-	go startForegroundWatcher()
 	go startKioskWatchdog()
-	go startProcessDeathWatcher(sigChan)
 
-	fmt.Println("[*] Listening for events on " + touchDevice)
-	go processInput()
+	engine := newGestureEngine()
+	startClipboardSync()
+	// Once the engine runs, cfg is its alone; everything else works from
+	// this copy.
+	settings := newLinkSettings()
+	linkPort := startAppLink(engine, settings, sigChan)
+	go watchAppByADB(sigChan)
+	input = newInputSource(cfg.Input)
+	go processInput(input, engine)
+	controlPath := startControlServer(engine, settings)
 
 	<-sigChan
-	isExiting = true
+	isExiting.Store(true)
+	if controlPath != "" {
+		_ = os.Remove(controlPath)
+	}
+	if linkPort != 0 {
+		runADB("forward", "--remove", "tcp:"+strconv.Itoa(linkPort))
+	}
 	cleanup(tmpAPK)
 }
 
-func setupEnvironment() {
+func setupEnvironment(p PowerConfig) {
 	runADB("shell", "settings", "put", "system", "accelerometer_rotation", "0")
 	runADB("shell", "settings", "put", "system", "user_rotation", "3")
 	runADB("shell", "settings", "put", "global", "policy_control", "immersive.full=sticky:*")
 	runADB("shell", "settings", "put", "secure", "immersive_mode_confirmations", "confirmed")
-	runADB("shell", "svc", "power", "stayon", "true")
+	if !p.WakeLock {
+		runADB("shell", "svc", "power", "stayon", "true")
+	}
+	dimScreen(p)
 }
 
 func launchApp() {
 	// Added -f 0x10000000 (FLAG_ACTIVITY_NEW_TASK) to allow the background script to force the UI to the front.
-	runADB("shell", "am", "start", "-n", pkgName+"/"+activityName, "-f", "0x10000000")
-}
-
-func startForegroundWatcher() {
-	ticker := time.NewTicker(500 * time.Millisecond)
-	for range ticker.C {
-		if isExiting {
-			return
-		}
-		out, err := exec.Command(adbPath, "shell", "dumpsys", "window", "displays", "|", "grep", "mCurrentFocus").Output()
-		if err == nil {
-			appInForeground = strings.Contains(string(out), pkgName)
-		}
-	}
+	// The app only accepts a host that knows the token it was started with.
+	runADB("shell", "am", "start", "-n", pkgName+"/"+activityName, "-f", "0x10000000",
+		"--es", proto.TokenExtra, appToken)
 }
 
 func startKioskWatchdog() {
 	for {
-		if isExiting {
+		if isExiting.Load() {
 			return
 		}
-		if !appInForeground {
+		// A pause from the phone lifts kiosk enforcement until resumed.
+		if !appInForeground.Load() && !appPaused.Load() {
 			fmt.Println("[!] Focus lost. Re-applying orientation and returning to app...")
 			runADB("shell", "settings", "put", "system", "user_rotation", "3")
 			launchApp()
@@ -124,129 +149,22 @@ func startKioskWatchdog() {
 	}
 }
 
-func startProcessDeathWatcher(sigChan chan os.Signal) {
-	ticker := time.NewTicker(1 * time.Second)
-	for range ticker.C {
-		if isExiting {
-			return
-		}
-		out, _ := exec.Command(adbPath, "shell", "pidof", pkgName).Output()
-		if len(strings.TrimSpace(string(out))) == 0 {
-			fmt.Println("\n[!] App process manually closed. Exiting...")
-			select {
-			case sigChan <- syscall.SIGTERM:
-			default:
-			}
-			return
+// processInput runs the gesture engine on the input source on this
+// goroutine. The source reads on its own goroutines, so the engine never
+// blocks on adb or the app link.
+func processInput(source inputSource, engine *gestureEngine) {
+	defer close(inputDone)
+
+	// Run returns when the stream ends, e.g. the phone disconnects, and
+	// releases anything held so no drag is left stuck down.
+	if err := engine.Run(source); err != nil {
+		if !isExiting.Load() {
+			fmt.Printf("[-] Failed to start input: %v\n", err)
 		}
+		return
 	}
-}
-
-func processInput() {
-	inputCmd = exec.Command(adbPath, "shell", "getevent", "-l")
-	stdout, _ := inputCmd.StdoutPipe()
-	inputCmd.Start()
-
-	scanner := bufio.NewScanner(stdout)
-	rePos := regexp.MustCompile(`ABS_MT_POSITION_(X|Y)\s+([0-9a-fA-F]+)`)
-
-	var lastX, lastY, curX, curY int
-	var touchStartTime time.Time
-	var lastReleaseTime time.Time
-	var hasMoved, rightClickDone, isDragging bool
-	var lastTapWasPure bool
-	var rightClickTimer *time.Timer
-	var scrollAccum float64
-	activeFingers := 0
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if strings.Contains(line, "ABS_MT_TRACKING_ID") {
-			if strings.Contains(line, "ffffffff") {
-				if activeFingers > 0 {
-					activeFingers--
-				}
-				if isDragging {
-					driver.Button("left", false)
-					isDragging = false
-				}
-				lastTapWasPure = !hasMoved && activeFingers == 0
-				lastReleaseTime = time.Now()
-			} else {
-				activeFingers++
-				lastX, lastY = 0, 0
-				if lastTapWasPure && time.Since(lastReleaseTime) < doubleTapTimeout {
-					isDragging = true
-					driver.Button("left", true)
-				}
-			}
-		}
-
-		if strings.Contains(line, "BTN_TOUCH") {
-			if strings.Contains(line, " DOWN") {
-				touchStartTime = time.Now()
-				hasMoved, rightClickDone = false, false
-				if !isDragging {
-					rightClickTimer = time.AfterFunc(longPressTimeout, func() {
-						if !hasMoved && !rightClickDone && activeFingers == 1 {
-							driver.Button("right", true)
-							driver.Button("right", false)
-							rightClickDone = true
-						}
-					})
-				}
-			} else if strings.Contains(line, " UP") {
-				if rightClickTimer != nil {
-					rightClickTimer.Stop()
-				}
-				if !isDragging && !hasMoved && !rightClickDone && time.Since(touchStartTime) < tapTimeout {
-					driver.Button("left", true)
-					driver.Button("left", false)
-				}
-			}
-		}
-
-		if strings.Contains(line, touchDevice) {
-			if matches := rePos.FindStringSubmatch(line); len(matches) > 0 {
-				val, _ := strconv.ParseInt(matches[2], 16, 64)
-				if matches[1] == "X" {
-					curX = int(val)
-				} else {
-					curY = int(val)
-				}
-			}
-
-			if strings.Contains(line, "SYN_REPORT") {
-				if !appInForeground {
-					lastX, lastY = 0, 0
-					continue
-				}
-
-				if lastX != 0 && lastY != 0 && curX != 0 && curY != 0 {
-					dx := int32(float64(lastY-curY) * sensitivity)
-					dy := int32(float64(curX-lastX) * sensitivity)
-
-					if dx != 0 || dy != 0 {
-						hasMoved = true
-						if rightClickTimer != nil {
-							rightClickTimer.Stop()
-						}
-
-						if activeFingers >= 2 {
-							scrollAccum += float64(dy) * 0.1
-							if scrollAccum >= 1.0 || scrollAccum <= -1.0 {
-								driver.Scroll(int32(scrollAccum * float64(scrollSens)))
-								scrollAccum = 0
-							}
-						} else {
-							driver.Move(dx, dy)
-						}
-					}
-				}
-				lastX, lastY = curX, curY
-			}
-		}
+	if !isExiting.Load() {
+		fmt.Println("[!] Input stream ended.")
 	}
 }
 
@@ -254,8 +172,13 @@ func runADB(args ...string) { _ = exec.Command(adbPath, args...).Run() }
 
 func cleanup(tmpPath string) {
 	fmt.Println("\n[*] Restoring Device Settings...")
-	if inputCmd != nil && inputCmd.Process != nil {
-		_ = inputCmd.Process.Kill()
+	if input != nil {
+		input.Close()
+	}
+	// The engine owns the driver until it has released its buttons.
+	select {
+	case <-inputDone:
+	case <-time.After(2 * time.Second):
 	}
 	if driver != nil {
 		driver.Close()
@@ -263,6 +186,7 @@ func cleanup(tmpPath string) {
 	runADB("shell", "settings", "put", "system", "accelerometer_rotation", "1")
 	runADB("shell", "settings", "put", "global", "policy_control", "null")
 	runADB("shell", "svc", "power", "stayon", "false")
+	restoreBrightness()
 	runADB("shell", "am", "force-stop", pkgName)
 	runADB("uninstall", pkgName)
 	_ = os.Remove(tmpPath)
@@ -0,0 +1,41 @@
+package main
+
+import "math"
+
+// motionAccumulator converts scaled digitizer deltas into whole pointer
+// counts while carrying the fractional remainder of each axis over to the
+// next frame. Truncating every frame on its own loses slow movements
+// entirely and biases drift towards zero.
+type motionAccumulator struct {
+	remX, remY float64
+}
+
+// motionEpsilon absorbs floating-point error in the carried remainder, so
+// a distance split into many small frames adds up to the same whole counts
+// as one large frame.
+const motionEpsilon = 1e-9
+
+// Add scales the raw delta by sens, folds in the carried remainder and
+// returns the whole part that should be sent to the driver.
+func (m *motionAccumulator) Add(rawX, rawY, sens float64) (int32, int32) {
+	fx := rawX*sens + m.remX
+	fy := rawY*sens + m.remY
+	dx, dy := wholeCounts(fx), wholeCounts(fy)
+	m.remX = fx - float64(dx)
+	m.remY = fy - float64(dy)
+	return dx, dy
+}
+
+// wholeCounts truncates f towards zero, treating values within
+// motionEpsilon of an integer as that integer.
+func wholeCounts(f float64) int32 {
+	if r := math.Round(f); math.Abs(f-r) < motionEpsilon {
+		return int32(r)
+	}
+	return int32(f)
+}
+
+// Reset drops any carried remainder, e.g. when a new touch starts.
+func (m *motionAccumulator) Reset() {
+	m.remX, m.remY = 0, 0
+}
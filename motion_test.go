@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+// addFrames feeds a total raw distance through m in frames equal steps and
+// returns the summed pointer motion.
+func addFrames(m *motionAccumulator, totalX, totalY, frames int, sens float64) (int, int) {
+	var x, y int
+	for range frames {
+		dx, dy := m.Add(float64(totalX/frames), float64(totalY/frames), sens)
+		x, y = x+int(dx), y+int(dy)
+	}
+	return x, y
+}
+
+func TestMotionIndependentOfFrameSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		sens   float64
+		dx, dy int // total raw distance
+	}{
+		{"unit", 1, 0, -600},
+		{"slow", 0.37, 0, -600},
+		{"fast", 2.5, 360, 0},
+		{"diagonal", 1.3, -240, 480},
+		{"fine", 0.05, 120, -360},
+	}
+	// Each total is split into frames of these sizes; all must move the
+	// pointer the same distance as a single frame does.
+	splits := []int{2, 3, 4, 5, 6, 10, 12, 24, 40, 60, 120}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var whole motionAccumulator
+			wantX, wantY := addFrames(&whole, tt.dx, tt.dy, 1, tt.sens)
+			if wantX == 0 && wantY == 0 {
+				t.Fatal("no motion in one frame")
+			}
+			for _, frames := range splits {
+				if tt.dx%frames != 0 || tt.dy%frames != 0 {
+					t.Fatalf("%d frames do not divide (%d, %d)", frames, tt.dx, tt.dy)
+				}
+				var m motionAccumulator
+				if x, y := addFrames(&m, tt.dx, tt.dy, frames, tt.sens); x != wantX || y != wantY {
+					t.Errorf("1 frame moved (%d, %d), %d frames moved (%d, %d)", wantX, wantY, frames, x, y)
+				}
+			}
+		})
+	}
+}
+
+func TestMotionSlowSwipe(t *testing.T) {
+	// One raw unit a frame at low sensitivity truncates to nothing on its
+	// own; carried over, 600 frames move 30 counts.
+	var m motionAccumulator
+	if x, y := addFrames(&m, 600, -600, 600, 0.05); x != 30 || y != -30 {
+		t.Errorf("slow swipe moved (%d, %d), want (30, -30)", x, y)
+	}
+}
+
+func TestMotionReset(t *testing.T) {
+	var m motionAccumulator
+	m.Add(1, 1, 0.9)
+	m.Reset()
+	if dx, dy := m.Add(1, 1, 0.5); dx != 0 || dy != 0 {
+		t.Errorf("remainder survived Reset: moved (%d, %d)", dx, dy)
+	}
+}
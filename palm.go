@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/mmngadi/touchpad-tool/internal/layout"
+)
+
+// contactClass says whether a contact takes part in gesture recognition.
+type contactClass int
+
+const (
+	contactFinger     contactClass = iota
+	contactPending                 // landed in an edge zone, not decided yet
+	contactPalm                    // rejected for the rest of its life
+	contactZone                    // resting in a button zone, see cfg.Zones
+	contactEdgeScroll              // landed alone in an edge-scroll strip
+)
+
+// Edges are strip widths along each side of the pad, in pad space (0..1).
+type Edges struct {
+	Left   float64 `json:"left"`
+	Right  float64 `json:"right"`
+	Top    float64 `json:"top"`
+	Bottom float64 `json:"bottom"`
+}
+
+// Contains reports whether the pad-space point lies in any of the strips.
+func (z Edges) Contains(x, y float64) bool {
+	return x < z.Left || x > 1-z.Right || y < z.Top || y > 1-z.Bottom
+}
+
+// PalmConfig tunes how contacts are classified as palms or resting thumbs.
+type PalmConfig struct {
+	// MaxSize and MaxPressure are fractions of the digitizer's reported
+	// range; anything larger is a palm. Zero disables the check.
+	MaxSize     float64 `json:"max_size"`
+	MaxPressure float64 `json:"max_pressure"`
+
+	// Contacts landing in Edges are ignored until they move out of the
+	// strip. If they are still inside after EdgeGraceMs they become palms.
+	Edges       Edges `json:"edges"`
+	EdgeGraceMs int   `json:"edge_grace_ms"`
+
+	// A finger that has rested without moving for ThumbRestMs when another
+	// finger lands is taken to be a thumb holding the phone.
+	ThumbRestMs int `json:"thumb_rest_ms"`
+}
+
+// restTravel is how far (pad space) a contact may drift and still count as
+// resting for thumb detection.
+const restTravel = 0.02
+
+// classifyContacts updates the class of every active contact for this frame.
+// Palm is sticky: once a contact is rejected it stays rejected until lifted.
+func classifyContacts(active []*touchContact, pad digitizer, now time.Time) {
+	p := cfg.Palm
+	newcomer := false
+	for _, c := range active {
+		if c.fresh {
+			newcomer = true
+		}
+	}
+
+	for _, c := range active {
+		if c.class == contactPalm || c.class == contactZone || c.class == contactEdgeScroll {
+			continue
+		}
+		if x, y := pad.Normalize(c.x, c.y); c.fresh && (layout.PauseButton.Contains(x, y) || layout.KeyboardButton.Contains(x, y)) {
+			// The app's own controls; the touch is not for the host.
+			c.class = contactPalm
+			continue
+		}
+		if p.MaxSize > 0 && pad.maxMajor > 0 && float64(c.major)/float64(pad.maxMajor) > p.MaxSize {
+			c.class = contactPalm
+			continue
+		}
+		if p.MaxPressure > 0 && pad.maxPressure > 0 && float64(c.pressure)/float64(pad.maxPressure) > p.MaxPressure {
+			c.class = contactPalm
+			continue
+		}
+
+		// Palm checks come first, so a palm or a graze along an edge
+		// never presses a zone or starts an edge scroll.
+		x, y := pad.Normalize(c.x, c.y)
+		if c.fresh {
+			switch {
+			case p.Edges.Contains(x, y):
+				c.class = contactPending
+			case zoneAt(c, pad) >= 0:
+				c.class = contactZone
+			case len(active) == 1:
+				if axis := edgeScrollAxis(c, pad); axis != 0 {
+					c.class, c.scrollAxis = contactEdgeScroll, axis
+				}
+			}
+			continue
+		}
+		if c.class == contactPending {
+			switch {
+			case !p.Edges.Contains(x, y):
+				c.class = contactFinger
+			case now.Sub(c.downAt) > time.Duration(p.EdgeGraceMs)*time.Millisecond:
+				c.class = contactPalm
+			}
+			continue
+		}
+
+		if newcomer && p.ThumbRestMs > 0 && now.Sub(c.downAt) > time.Duration(p.ThumbRestMs)*time.Millisecond {
+			sx, sy := pad.Normalize(c.startX, c.startY)
+			if math.Hypot(x-sx, y-sy) < restTravel {
+				c.class = contactPalm
+			}
+		}
+	}
+}
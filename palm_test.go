@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recording is a `getevent -lt` capture from testdata. Its comment header
+// gives the ranges of the digitizer it was made on and the tracking IDs,
+// in hex, that must be classified as palms; see testdata/README.md.
+//
+//	# pad: x=1080 y=2400 major=255 pressure=0
+//	# rejected: 2a2 2a3
+type recording struct {
+	pad      digitizer
+	rejected []int
+	events   []recordedEvent
+}
+
+type recordedEvent struct {
+	at   float64 // seconds, as stamped by getevent
+	code string
+	val  int
+}
+
+func readRecording(t *testing.T, name string) recording {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var r recording
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if comment, ok := strings.CutPrefix(line, "#"); ok {
+			key, val, _ := strings.Cut(comment, ":")
+			switch strings.TrimSpace(key) {
+			case "pad":
+				r.pad = parsePadHeader(t, name, val)
+			case "rejected":
+				for _, id := range strings.Fields(val) {
+					v, err := strconv.ParseInt(id, 16, 32)
+					if err != nil {
+						t.Fatalf("%s:%d: bad tracking ID %q", name, n, id)
+					}
+					r.rejected = append(r.rejected, int(v))
+				}
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		stamp, rest, ok := strings.Cut(strings.TrimPrefix(line, "["), "] ")
+		if !ok {
+			t.Fatalf("%s:%d: no timestamp", name, n)
+		}
+		at, err := strconv.ParseFloat(strings.TrimSpace(stamp), 64)
+		if err != nil {
+			t.Fatalf("%s:%d: %v", name, n, err)
+		}
+		// Captures come from whichever node the phone's digitizer is.
+		_, event, _ := strings.Cut(rest, ": ")
+		code, val, ok := parseEventLine(touchDevice + ": " + event)
+		if !ok {
+			t.Fatalf("%s:%d: bad event %q", name, n, rest)
+		}
+		r.events = append(r.events, recordedEvent{at, code, val})
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if r.pad.maxX == 0 || r.pad.maxY == 0 {
+		t.Fatalf("%s: no # pad: header with the digitizer ranges", name)
+	}
+	slices.Sort(r.rejected)
+	return r
+}
+
+func parsePadHeader(t *testing.T, name, s string) digitizer {
+	t.Helper()
+	var d digitizer
+	for _, f := range strings.Fields(s) {
+		k, v, _ := strings.Cut(f, "=")
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			t.Fatalf("%s: bad pad range %q", name, f)
+		}
+		switch k {
+		case "x":
+			d.maxX = n
+		case "y":
+			d.maxY = n
+		case "major":
+			d.maxMajor = n
+		case "pressure":
+			d.maxPressure = n
+		default:
+			t.Fatalf("%s: unknown pad range %q", name, k)
+		}
+	}
+	return d
+}
+
+// replay feeds a recording to the engine, clocking the contact tracker by
+// the recorded timestamps. It returns the tracking IDs that were
+// classified as palms at any point.
+func replay(t *testing.T, e *gestureEngine, name string, r recording) []int {
+	t.Helper()
+	start := time.Now()
+	clock := start
+	e.contacts.now = func() time.Time { return clock }
+
+	rejected := map[int]bool{}
+	for _, ev := range r.events {
+		clock = start.Add(time.Duration((ev.at - r.events[0].at) * float64(time.Second)))
+		if !e.contacts.Handle(ev.code, ev.val) {
+			continue
+		}
+		e.Frame()
+		for _, c := range e.contacts.Active() {
+			if c.class == contactPalm {
+				rejected[c.id] = true
+			}
+		}
+	}
+	if len(e.contacts.Active()) != 0 {
+		t.Fatalf("%s: contacts still down at the end", name)
+	}
+	return slices.Sorted(maps.Keys(rejected))
+}
+
+// TestPalmRejectionRecordings replays every capture in testdata. Each one
+// is of fingers moving the pointer while palms, thumbs or edge grazes are
+// down, so nothing may click or scroll and the pointer must move.
+func TestPalmRejectionRecordings(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no recordings in testdata")
+	}
+	for _, file := range files {
+		name := filepath.Base(file)
+		t.Run(name, func(t *testing.T) {
+			r := readRecording(t, name)
+			e, f := newTestEngine(t)
+			e.pad = r.pad
+			got := replay(t, e, name, r)
+			if !slices.Equal(got, r.rejected) {
+				t.Errorf("rejected %#x, want %#x", got, r.rejected)
+			}
+			for _, ev := range f.Events() {
+				t.Errorf("unexpected output %q", ev)
+			}
+			if x, y := f.Motion(); x == 0 && y == 0 {
+				t.Error("the kept finger did not move the pointer")
+			}
+		})
+	}
+}
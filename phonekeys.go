@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mmngadi/touchpad-tool/internal/drivers"
+)
+
+// phoneKey runs the binding for a hardware button on the phone. A bound
+// mouse button follows the key, so holding the key holds the button for
+// dragging; the rest of the action runs when the key goes down.
+func (e *gestureEngine) phoneKey(name string, down bool) {
+	if held, ok := e.heldKeys[name]; ok && !down {
+		delete(e.heldKeys, name)
+		driver.Button(held, false)
+		return
+	}
+	action, ok := binding(name)
+	if !ok || !down || e.paused {
+		return
+	}
+	if action.Button != "" {
+		if _, ok := e.heldKeys[name]; !ok {
+			e.heldKeys[name] = action.Button
+			driver.Button(action.Button, true)
+			haptic(hapticClick)
+		}
+		action.Button = ""
+	}
+	action.Run()
+}
+
+// releasePhoneKeys lets go of every mouse button held by a phone key.
+func (e *gestureEngine) releasePhoneKeys() {
+	for name, button := range e.heldKeys {
+		delete(e.heldKeys, name)
+		driver.Button(button, false)
+	}
+}
+
+// warnedUntypeable is set once the user has been told that some typed
+// characters cannot be entered on this PC.
+var warnedUntypeable bool
+
+// typed enters text from the phone's keyboard, or presses the editing key
+// it sent, unless input is paused.
+func (e *gestureEngine) typed(text, key string) {
+	if e.paused {
+		return
+	}
+	if key != "" {
+		if k, ok := drivers.LookupKey(key); ok {
+			drivers.Chord{k}.Press(driver)
+		}
+		return
+	}
+	if n := driver.Type(text); n > 0 && !warnedUntypeable {
+		fmt.Printf("[!] %d typed character(s) cannot be entered through the virtual keyboard\n", n)
+		warnedUntypeable = true
+	}
+}
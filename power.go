@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// PowerConfig trades on-screen feedback for battery life on long sessions.
+type PowerConfig struct {
+	// LowPower turns the phone's brightness down to its minimum while the
+	// session is active and stops the app from repainting after its first
+	// frame.
+	LowPower bool `json:"low_power"`
+
+	// WakeLock keeps the screen on with a wake lock held by the app instead
+	// of `svc power stayon`, so the phone can go back to sleep as soon as
+	// the app is closed or moves to the background.
+	WakeLock bool `json:"wake_lock"`
+
+	// BatteryWarning is the charge, in percent, below which the tool warns
+	// while the phone is not charging. Zero turns the warning off.
+	BatteryWarning int `json:"battery_warning"`
+}
+
+var (
+	batteryLevel    atomic.Int32 // percent, -1 until the app reports it
+	batteryCharging atomic.Bool
+	batteryWarned   bool // guarded by batteryMu
+	batteryMu       sync.Mutex
+
+	// batteryWarning is cfg.Power.BatteryWarning, set before the link
+	// starts so noteBattery never reads cfg.
+	batteryWarning atomic.Int32
+
+	// The phone's own brightness settings, saved by dimScreen so they can
+	// be put back. Empty while nothing is saved.
+	savedBrightness, savedBrightnessMode string
+	brightnessMu                         sync.Mutex
+)
+
+func init() {
+	batteryLevel.Store(-1)
+}
+
+// noteBattery records a battery report from the app and warns once each
+// time the charge drops below batteryWarning unplugged.
+func noteBattery(level int, charging bool) {
+	batteryLevel.Store(int32(level))
+	batteryCharging.Store(charging)
+
+	batteryMu.Lock()
+	defer batteryMu.Unlock()
+	low := !charging && level <= int(batteryWarning.Load())
+	if low && !batteryWarned {
+		fmt.Printf("[!] Phone battery low: %d%%\n", level)
+	}
+	batteryWarned = low
+}
+
+// dimScreen turns the phone's brightness down to its minimum in low power
+// mode, saving the user's settings the first time.
+func dimScreen(p PowerConfig) {
+	if !p.LowPower {
+		return
+	}
+	brightnessMu.Lock()
+	defer brightnessMu.Unlock()
+	if isExiting.Load() {
+		// cleanup may already have put the user's brightness back.
+		return
+	}
+	if savedBrightness == "" {
+		level, err1 := adbOutput("shell", "settings", "get", "system", "screen_brightness")
+		mode, err2 := adbOutput("shell", "settings", "get", "system", "screen_brightness_mode")
+		if err1 != nil || err2 != nil {
+			fmt.Println("[!] Could not read the phone's brightness, leaving it alone")
+			return
+		}
+		savedBrightness, savedBrightnessMode = level, mode
+	}
+	runADB("shell", "settings", "put", "system", "screen_brightness_mode", "0")
+	runADB("shell", "settings", "put", "system", "screen_brightness", "1")
+}
+
+// restoreBrightness puts back the settings saved by dimScreen. They stay
+// saved if that fails, so the next dimScreen does not take the dimmed
+// brightness for the user's own and a later call can try again.
+func restoreBrightness() {
+	brightnessMu.Lock()
+	defer brightnessMu.Unlock()
+	if savedBrightness == "" {
+		return
+	}
+	_, err1 := adbOutput("shell", "settings", "put", "system", "screen_brightness", savedBrightness)
+	_, err2 := adbOutput("shell", "settings", "put", "system", "screen_brightness_mode", savedBrightnessMode)
+	if err1 != nil || err2 != nil {
+		fmt.Println("[!] Could not restore the phone's brightness")
+		return
+	}
+	savedBrightness, savedBrightnessMode = "", ""
+}
+
+func adbOutput(args ...string) (string, error) {
+	out, err := exec.Command(adbPath, args...).Output()
+	return strings.TrimSpace(string(out)), err
+}
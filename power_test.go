@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSettings is an adb that keeps `settings` values in $state. Creating
+// $state/fail.<namespace>.<name> makes the next put of that setting fail.
+const fakeSettings = `
+[ "$1" = shell ] && [ "$2" = settings ] || exit 0
+case "$3" in
+get) cat "$state/$4.$5" 2>/dev/null || echo null ;;
+put)
+	if [ -e "$state/fail.$4.$5" ]; then rm "$state/fail.$4.$5"; exit 1; fi
+	echo "$6" > "$state/$4.$5" ;;
+esac`
+
+func TestBrightnessAcrossPauses(t *testing.T) {
+	r := startEngine(t)
+	dir := fakeADB(t, fakeSettings)
+	t.Cleanup(func() {
+		appPaused.Store(false)
+		savedBrightness, savedBrightnessMode = "", ""
+	})
+
+	setting := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, "system."+name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(data))
+	}
+	setBrightness := func(level, mode string) {
+		os.WriteFile(filepath.Join(dir, "system.screen_brightness"), []byte(level+"\n"), 0644)
+		os.WriteFile(filepath.Join(dir, "system.screen_brightness_mode"), []byte(mode+"\n"), 0644)
+	}
+	check := func(when, level, mode string) {
+		t.Helper()
+		if got, gotMode := setting("screen_brightness"), setting("screen_brightness_mode"); got != level || gotMode != mode {
+			t.Fatalf("%s: brightness %s mode %s, want %s mode %s", when, got, gotMode, level, mode)
+		}
+	}
+
+	cfg.Power.LowPower = true
+	s := newLinkSettings()
+	setBrightness("180", "1")
+	dimScreen(s.power)
+	check("dimmed", "1", "0")
+
+	for range 3 {
+		pauseSession(r.e, "the test")
+		check("paused", "180", "1")
+		resumeSession(r.e, s, "the test")
+		check("resumed", "1", "0")
+	}
+
+	// Changed by the user while paused: that is what is put back later.
+	pauseSession(r.e, "the test")
+	setBrightness("90", "0")
+	resumeSession(r.e, s, "the test")
+	check("resumed after a change", "1", "0")
+
+	// A restore that fails keeps the saved brightness for the next one.
+	os.WriteFile(filepath.Join(dir, "fail.system.screen_brightness"), nil, 0644)
+	pauseSession(r.e, "the test")
+	resumeSession(r.e, s, "the test")
+	check("resumed after a failed restore", "1", "0")
+	pauseSession(r.e, "the test")
+	check("paused again", "90", "0")
+
+	resumeSession(r.e, s, "the test")
+	restoreBrightness()
+	check("exit", "90", "0")
+}
+
+func TestNoteBattery(t *testing.T) {
+	old := batteryWarning.Load()
+	t.Cleanup(func() {
+		batteryWarning.Store(old)
+		batteryWarned = false
+	})
+	batteryWarning.Store(20)
+	batteryWarned = false
+
+	steps := []struct {
+		level    int
+		charging bool
+		warned   bool
+	}{
+		{50, false, false},
+		{20, false, true},
+		{15, false, true},
+		{15, true, false},
+		{10, false, true},
+		{30, false, false},
+	}
+	for _, st := range steps {
+		noteBattery(st.level, st.charging)
+		if batteryWarned != st.warned {
+			t.Errorf("at %d%% (charging %v): warned = %v, want %v", st.level, st.charging, batteryWarned, st.warned)
+		}
+		if got := batteryLevel.Load(); got != int32(st.level) {
+			t.Errorf("battery level = %d, want %d", got, st.level)
+		}
+	}
+}
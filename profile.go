@@ -0,0 +1,37 @@
+package main
+
+// Scroll modes a profile can select.
+const (
+	scrollTwoFinger = "two-finger"
+	scrollEdge      = "edge"
+	scrollBoth      = "both"
+)
+
+// Profile groups settings people tend to switch between as a set, such as
+// two-handed versus one-handed use.
+type Profile struct {
+	// ScrollMode is "two-finger", "edge" or "both".
+	ScrollMode string `json:"scroll_mode"`
+
+	// EdgeScroll is the strip layout for edge scrolling. A single finger that
+	// lands in the left or right strip scrolls vertically; top or bottom
+	// scrolls horizontally.
+	EdgeScroll Edges `json:"edge_scroll"`
+}
+
+func (p Profile) twoFingerScroll() bool {
+	return p.ScrollMode != scrollEdge
+}
+
+func (p Profile) edgeScroll() bool {
+	return p.ScrollMode == scrollEdge || p.ScrollMode == scrollBoth
+}
+
+// activeProfile returns the profile named by cfg.Profile, or the built-in
+// default if that name is unknown.
+func activeProfile() Profile {
+	if p, ok := cfg.Profiles[cfg.Profile]; ok {
+		return p
+	}
+	return defaultConfig().Profiles["default"]
+}
@@ -0,0 +1,161 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/mmngadi/touchpad-tool/internal/drivers"
+)
+
+const setupLinuxUsage = `usage: touchpad-tool setup-linux
+
+Checks what touchpad-tool needs to run without root: the uinput module, a
+udev rule giving the input group access to /dev/uinput, and your membership
+of that group. Run it with sudo to fix what it finds.
+`
+
+const uinputGroup = "input"
+
+// modulesLoadPath has the uinput module loaded at boot.
+const modulesLoadPath = "/etc/modules-load.d/touchpad-tool.conf"
+
+// runSetupLinux implements the `setup-linux` subcommand. It returns the
+// process exit code.
+func runSetupLinux(args []string) int {
+	if len(args) != 0 {
+		fmt.Print(setupLinuxUsage)
+		return 2
+	}
+	cfg = loadConfig()
+	root := os.Geteuid() == 0
+
+	// Under sudo, set up the user who ran it rather than root.
+	name := os.Getenv("SUDO_USER")
+	if name == "" {
+		if cur, err := user.Current(); err == nil {
+			name = cur.Username
+		}
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		fmt.Printf("[-] Cannot look up user %q: %v\n", name, err)
+		return 1
+	}
+	if root && u.Uid == "0" {
+		fmt.Println("[-] Run setup-linux with sudo from your own account, not as root.")
+		return 1
+	}
+
+	problems, relogin := 0, false
+	// fix runs a command that fixes a problem, as long as we are root.
+	fix := func(done string, cmd ...string) bool {
+		if !root {
+			return false
+		}
+		if out, err := exec.Command(cmd[0], cmd[1:]...).CombinedOutput(); err != nil {
+			fmt.Printf("[-] %s failed: %v %s\n", strings.Join(cmd, " "), err, bytes.TrimSpace(out))
+			return false
+		}
+		fmt.Printf("[+] %s.\n", done)
+		return true
+	}
+
+	// The kernel module behind /dev/uinput.
+	if _, err := os.Stat("/sys/class/misc/uinput"); err != nil {
+		fmt.Println("[!] The uinput kernel module is not loaded.")
+		problems++
+		if fix("Loaded the uinput module", "modprobe", "uinput") {
+			if err := os.WriteFile(modulesLoadPath, []byte("uinput\n"), 0644); err == nil {
+				fmt.Printf("[+] Wrote %s so it loads at boot.\n", modulesLoadPath)
+			}
+		}
+	}
+
+	// The group that may open /dev/uinput, and the user's membership.
+	group, err := user.LookupGroup(uinputGroup)
+	if err != nil {
+		fmt.Printf("[!] There is no %s group.\n", uinputGroup)
+		problems++
+		if fix("Created the "+uinputGroup+" group", "groupadd", "--system", uinputGroup) {
+			group, _ = user.LookupGroup(uinputGroup)
+		}
+	}
+	if group != nil {
+		gids, _ := u.GroupIds()
+		if !slices.Contains(gids, group.Gid) {
+			fmt.Printf("[!] %s is not in the %s group.\n", u.Username, uinputGroup)
+			problems++
+			relogin = fix("Added "+u.Username+" to the "+uinputGroup+" group", "usermod", "-aG", uinputGroup, u.Username)
+		}
+	}
+
+	// The udev rule that hands /dev/uinput to the group.
+	if have, err := os.ReadFile(udevRulesPath); err != nil || string(have) != udevRules(cfg.Device) {
+		fmt.Printf("[!] The udev rules in %s are missing or out of date.\n", udevRulesPath)
+		problems++
+		if root {
+			if err := installUdev(cfg.Device); err != nil {
+				fmt.Printf("[-] %v\n", err)
+			}
+		}
+	}
+
+	// What the device node looks like now, after any fixes.
+	if info, err := os.Stat(drivers.UinputPath); err == nil && group != nil {
+		st := info.Sys().(*syscall.Stat_t)
+		owner := strconv.Itoa(int(st.Gid))
+		if g, err := user.LookupGroupId(owner); err == nil {
+			owner = g.Name
+		}
+		if strconv.Itoa(int(st.Gid)) != group.Gid || info.Mode().Perm()&0060 != 0060 {
+			fmt.Printf("[!] %s belongs to group %s with mode %v, so the %s group cannot open it.\n", drivers.UinputPath, owner, info.Mode().Perm(), uinputGroup)
+			problems++
+		}
+	}
+
+	// A root adb server left behind by an earlier `sudo touchpad-tool`
+	// would keep serving the phone as root.
+	if adbRunningAsRoot() {
+		fmt.Println("[!] An adb server is running as root; stop it with: sudo adb kill-server")
+		problems++
+	}
+
+	switch {
+	case problems == 0:
+		fmt.Println("[+] Ready: run touchpad-tool without sudo.")
+		return 0
+	case !root:
+		fmt.Println("[*] Run `sudo touchpad-tool setup-linux` to fix this.")
+		return 1
+	case relogin:
+		fmt.Printf("[*] Log out and back in so %s's new group takes effect, then run touchpad-tool without sudo.\n", u.Username)
+	default:
+		fmt.Println("[*] Run `touchpad-tool setup-linux` again without sudo to check.")
+	}
+	return 0
+}
+
+// adbRunningAsRoot reports whether any adb process is owned by root.
+func adbRunningAsRoot() bool {
+	procs, _ := filepath.Glob("/proc/[0-9]*/comm")
+	for _, p := range procs {
+		comm, err := os.ReadFile(p)
+		if err != nil || string(bytes.TrimSpace(comm)) != "adb" {
+			continue
+		}
+		if info, err := os.Stat(filepath.Dir(p)); err == nil && info.Sys().(*syscall.Stat_t).Uid == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+import "fmt"
+
+// runSetupLinux only has work to do on Linux.
+func runSetupLinux(args []string) int {
+	fmt.Println("[-] setup-linux is only needed on Linux.")
+	return 1
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+
+	"github.com/mmngadi/touchpad-tool/internal/proto"
+)
+
+// Input sources, selected with the "input" config key.
+const (
+	inputGetevent = "getevent"
+	inputApp      = "app"
+)
+
+// inputSource produces type B multitouch events for the gesture engine, the
+// same codes getevent reports for the touch device, so the contact tracker
+// does not care where they came from.
+type inputSource interface {
+	// Open starts the source. It returns the digitizer the positions are
+	// reported in and a channel of events that is closed when input ends.
+	Open() (digitizer, <-chan inputEvent, error)
+	// Close stops the source, which ends its channel. It may be called
+	// before or during Open.
+	Close()
+}
+
+var errSourceClosed = errors.New("input source closed")
+
+func newInputSource(name string) inputSource {
+	if name == inputApp {
+		return &appSource{stop: make(chan struct{})}
+	}
+	return &geteventSource{}
+}
+
+// geteventSource reads the touch device with `adb shell getevent`. It needs
+// the shell user to be able to read touchDevice.
+type geteventSource struct {
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	closed bool
+}
+
+func (s *geteventSource) Open() (digitizer, <-chan inputEvent, error) {
+	pad := probeDigitizer()
+	cmd := exec.Command(adbPath, "shell", "getevent", "-l")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return pad, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return pad, nil, errSourceClosed
+	}
+	if err := cmd.Start(); err != nil {
+		return pad, nil, err
+	}
+	s.cmd = cmd
+	fmt.Println("[*] Listening for events on " + touchDevice)
+
+	events := make(chan inputEvent, 256)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if code, val, ok := parseEventLine(scanner.Text()); ok {
+				events <- inputEvent{code: code, val: val}
+			}
+		}
+		_ = cmd.Wait()
+	}()
+	return pad, events, nil
+}
+
+func (s *geteventSource) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.cmd != nil && s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+	}
+}
+
+// appFrames carries Surface and Touch messages from the app link to the
+// app source. The link never blocks on it; a frame that does not fit is
+// lost, which the source notices from the sequence numbers.
+var appFrames = make(chan proto.Message, 256)
+
+// appSource turns touch frames captured by the app into multitouch events.
+// App coordinates are pixels in the landscape surface, so they are turned
+// back into the phone's natural orientation to match what a digitizer
+// reports; Normalize then maps them onto the same pad space as getevent.
+type appSource struct {
+	stop chan struct{}
+	once sync.Once
+
+	width, height int     // surface size the digitizer was built from
+	scaleX        float64 // current surface to original surface
+	scaleY        float64
+	seq           uint64       // last frame seen, 0 after a (re)connect
+	nextID        int          // next tracking ID to hand out
+	down          map[int]bool // app contact IDs currently down
+}
+
+func (s *appSource) Open() (digitizer, <-chan inputEvent, error) {
+	fmt.Println("[*] Waiting for touch input from the app...")
+	for {
+		select {
+		case <-s.stop:
+			return digitizer{}, nil, errSourceClosed
+		case m := <-appFrames:
+			if m.Type != proto.Surface || m.Width <= 0 || m.Height <= 0 {
+				continue
+			}
+			s.width, s.height = m.Width, m.Height
+			s.scaleX, s.scaleY = 1, 1
+			s.down = make(map[int]bool)
+			fmt.Printf("[*] Receiving touch input from the app (%dx%d)\n", m.Width, m.Height)
+
+			events := make(chan inputEvent, 256)
+			go s.run(events)
+			return digitizer{maxX: m.Height, maxY: m.Width}, events, nil
+		}
+	}
+}
+
+func (s *appSource) Close() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+func (s *appSource) run(events chan<- inputEvent) {
+	defer close(events)
+	emit := func(code string, val int) bool {
+		select {
+		case events <- inputEvent{code: code, val: val}:
+			return true
+		case <-s.stop:
+			return false
+		}
+	}
+
+	for {
+		var m proto.Message
+		select {
+		case <-s.stop:
+			return
+		case m = <-appFrames:
+		}
+
+		switch m.Type {
+		case proto.Surface:
+			// A new connection or a resized surface: nothing that was down
+			// can be trusted to finish.
+			if !s.liftAll(emit) {
+				return
+			}
+			s.seq = 0
+			if m.Width > 0 && m.Height > 0 {
+				s.scaleX = float64(s.width) / float64(m.Width)
+				s.scaleY = float64(s.height) / float64(m.Height)
+			}
+		case proto.Touch:
+			if s.seq != 0 && m.Seq != s.seq+1 {
+				// A count that went back is the app starting over, not
+				// frames lost, though what was down is stale either way.
+				if m.Seq > s.seq {
+					fmt.Printf("[!] Lost %d touch frames from the app\n", m.Seq-s.seq-1)
+				}
+				if !s.liftAll(emit) {
+					return
+				}
+			}
+			s.seq = m.Seq
+			if !s.frame(m.Contacts, emit) {
+				return
+			}
+		}
+	}
+}
+
+// frame emits one touch frame. Contacts that were not seen beginning, for
+// example after lost frames, are ignored until they end.
+func (s *appSource) frame(contacts []proto.Contact, emit func(string, int) bool) bool {
+	for _, c := range contacts {
+		if c.Phase != proto.PhaseBegin && !s.down[c.ID] {
+			continue
+		}
+		if !emit("ABS_MT_SLOT", c.ID) {
+			return false
+		}
+		switch c.Phase {
+		case proto.PhaseEnd:
+			delete(s.down, c.ID)
+			if !emit("ABS_MT_TRACKING_ID", -1) {
+				return false
+			}
+			continue
+		case proto.PhaseBegin:
+			s.down[c.ID] = true
+			s.nextID++
+			if !emit("ABS_MT_TRACKING_ID", s.nextID) {
+				return false
+			}
+		}
+		x := int(float64(c.Y) * s.scaleY)
+		y := s.width - int(float64(c.X)*s.scaleX)
+		if !emit("ABS_MT_POSITION_X", x) || !emit("ABS_MT_POSITION_Y", y) {
+			return false
+		}
+	}
+	return emit("SYN_REPORT", 0)
+}
+
+func (s *appSource) liftAll(emit func(string, int) bool) bool {
+	if len(s.down) == 0 {
+		return true
+	}
+	for id := range s.down {
+		if !emit("ABS_MT_SLOT", id) || !emit("ABS_MT_TRACKING_ID", -1) {
+			return false
+		}
+	}
+	clear(s.down)
+	return emit("SYN_REPORT", 0)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"math"
+
+	"github.com/mmngadi/touchpad-tool/internal/drivers"
+)
+
+var ctrlKey, _ = drivers.LookupKey("ctrl")
+
+// Two-finger gestures start undecided and lock into one mode as soon as one
+// of translation, pinch or rotation clearly dominates. Until then nothing is
+// emitted, which is what keeps a slightly uneven scroll from zooming.
+type twoFingerMode int
+
+const (
+	twoFingerUndecided twoFingerMode = iota
+	twoFingerScroll
+	twoFingerPinch
+	twoFingerRotate
+)
+
+const (
+	scrollLockDistance = 0.03               // centroid travel, pad space
+	pinchLockRatio     = 0.08               // |ln(distance/start distance)|
+	rotateLockAngle    = 12 * math.Pi / 180 // radians
+)
+
+type twoFingerState struct {
+	mode twoFingerMode
+	a, b int // tracking IDs the state was started for
+
+	startDist  float64
+	startCX    float64
+	startCY    float64
+	lastAngle  float64
+	angle      float64 // accumulated rotation since start, radians
+	pinchSteps int     // zoom notches already emitted
+	rotSteps   int     // rotate steps already emitted
+}
+
+// padVector returns the vector from a to b in raw units, turned into the
+// landscape orientation used for pad space. Raw units are used instead of
+// normalized ones so distances and angles are not skewed by the aspect ratio.
+func padVector(a, b *touchContact) (float64, float64) {
+	return float64(a.y - b.y), float64(b.x - a.x)
+}
+
+func (s *twoFingerState) reset(a, b *touchContact, pad digitizer) {
+	vx, vy := padVector(a, b)
+	ax, ay := pad.Normalize(a.x, a.y)
+	bx, by := pad.Normalize(b.x, b.y)
+	*s = twoFingerState{
+		a:         a.id,
+		b:         b.id,
+		startDist: math.Hypot(vx, vy),
+		startCX:   (ax + bx) / 2,
+		startCY:   (ay + by) / 2,
+		lastAngle: math.Atan2(vy, vx),
+	}
+}
+
+// trackTwoFinger decides between scroll, pinch and rotate for the first two
+// contacts and reports whether the caller should treat the frame as scroll.
+func (e *gestureEngine) trackTwoFinger(active []*touchContact) bool {
+	a, b := active[0], active[1]
+	s := &e.twoFinger
+	if s.a != a.id || s.b != b.id || s.startDist == 0 {
+		s.reset(a, b, e.pad)
+		return false
+	}
+
+	vx, vy := padVector(a, b)
+	angle := math.Atan2(vy, vx)
+	delta := angle - s.lastAngle
+	if delta > math.Pi {
+		delta -= 2 * math.Pi
+	} else if delta < -math.Pi {
+		delta += 2 * math.Pi
+	}
+	s.angle += delta
+	s.lastAngle = angle
+	ratio := math.Log(math.Hypot(vx, vy) / s.startDist)
+
+	if s.mode == twoFingerUndecided {
+		ax, ay := e.pad.Normalize(a.x, a.y)
+		bx, by := e.pad.Normalize(b.x, b.y)
+		travel := math.Hypot((ax+bx)/2-s.startCX, (ay+by)/2-s.startCY)
+
+		scores := map[twoFingerMode]float64{
+			twoFingerPinch: math.Abs(ratio) / pinchLockRatio,
+		}
+		if activeProfile().twoFingerScroll() {
+			scores[twoFingerScroll] = travel / scrollLockDistance
+		}
+		if anyBinding("rotate-") {
+			scores[twoFingerRotate] = math.Abs(s.angle) / rotateLockAngle
+		}
+		best, bestScore := twoFingerUndecided, 1.0
+		for mode, score := range scores {
+			if score >= bestScore {
+				best, bestScore = mode, score
+			}
+		}
+		if best == twoFingerUndecided {
+			return false
+		}
+		s.mode = best
+		e.hasMoved = true
+		e.stopLongPress()
+	}
+
+	switch s.mode {
+	case twoFingerPinch:
+		steps := int(ratio / cfg.PinchStep)
+		for ; s.pinchSteps < steps; s.pinchSteps++ {
+			e.zoom(1)
+		}
+		for ; s.pinchSteps > steps; s.pinchSteps-- {
+			e.zoom(-1)
+		}
+	case twoFingerRotate:
+		steps := int(s.angle / (cfg.RotateStep * math.Pi / 180))
+		for ; s.rotSteps < steps; s.rotSteps++ {
+			cfg.Bindings["rotate-cw"].Run()
+		}
+		for ; s.rotSteps > steps; s.rotSteps-- {
+			cfg.Bindings["rotate-ccw"].Run()
+		}
+	}
+	return s.mode == twoFingerScroll
+}
+
+// zoom sends one Ctrl+wheel notch, which desktop apps on both Windows and
+// Linux treat as zoom in (positive) or out (negative). It is always a
+// single click whatever the scroll speed, so each pinch step zooms once.
+func (e *gestureEngine) zoom(dir int32) {
+	driver.Key(ctrlKey, true)
+	driver.Scroll(dir * drivers.WheelNotch)
+	driver.Key(ctrlKey, false)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mmngadi/touchpad-tool/internal/drivers"
+)
+
+func TestPinchSendsOneNotchPerStep(t *testing.T) {
+	e, f := newTestEngine(t)
+	lines := []string{
+		"EV_ABS ABS_MT_SLOT 00000000",
+		"EV_ABS ABS_MT_TRACKING_ID 00000001",
+		"EV_ABS ABS_MT_POSITION_X 000001f4",
+		"EV_ABS ABS_MT_POSITION_Y 00000384",
+		"EV_ABS ABS_MT_SLOT 00000001",
+		"EV_ABS ABS_MT_TRACKING_ID 00000002",
+		"EV_ABS ABS_MT_POSITION_X 000001f4",
+		"EV_ABS ABS_MT_POSITION_Y 0000047e",
+		"EV_SYN SYN_REPORT 00000000",
+	}
+	// Spread the fingers apart along the long axis, 20 units a frame each.
+	for i := 1; i <= 15; i++ {
+		lines = append(lines,
+			"EV_ABS ABS_MT_SLOT 00000000",
+			fmt.Sprintf("EV_ABS ABS_MT_POSITION_Y %08x", 900-20*i),
+			"EV_ABS ABS_MT_SLOT 00000001",
+			fmt.Sprintf("EV_ABS ABS_MT_POSITION_Y %08x", 1150+20*i),
+			"EV_SYN SYN_REPORT 00000000")
+	}
+	feed(e, eventLines(t, lines...))
+
+	if e.twoFinger.mode != twoFingerPinch {
+		t.Fatalf("mode = %v, want pinch", e.twoFinger.mode)
+	}
+	events := f.Events()
+	if len(events) == 0 || len(events)%3 != 0 {
+		t.Fatalf("events = %q, want Ctrl+wheel triples", events)
+	}
+	notch := fmt.Sprintf("scroll %d", drivers.WheelNotch)
+	for i := 0; i < len(events); i += 3 {
+		got := events[i : i+3]
+		if got[0] != "key ctrl down" || got[1] != notch || got[2] != "key ctrl up" {
+			t.Fatalf("zoom step %d = %q, want [key ctrl down, %s, key ctrl up]", i/3, got, notch)
+		}
+	}
+	if steps := len(events) / 3; steps != e.twoFinger.pinchSteps {
+		t.Errorf("sent %d zoom steps, engine counted %d", steps, e.twoFinger.pinchSteps)
+	}
+}
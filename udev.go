@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+const udevUsage = `usage: touchpad-tool udev [install]
+
+Prints udev rules and a hwdb snippet for the virtual input device, built
+from the "device" settings in touchpad-tool.json. With install, writes them
+to /etc/udev (as root) and reloads udev.
+`
+
+const (
+	udevRulesPath = "/etc/udev/rules.d/70-touchpad-tool.rules"
+	udevHwdbPath  = "/etc/udev/hwdb.d/70-touchpad-tool.hwdb"
+)
+
+// udevRules lets the input group create uinput devices, so the tool can
+// run without root, and tags our device so other rules can refer to it.
+func udevRules(d DeviceConfig) string {
+	return fmt.Sprintf(`# Generated by touchpad-tool udev.
+
+# Members of the input group may create virtual input devices.
+KERNEL=="uinput", SUBSYSTEM=="misc", GROUP="input", MODE="0660", OPTIONS+="static_node=uinput"
+
+# The touchpad-tool device. Add libinput properties here, for example
+# ENV{LIBINPUT_IGNORE_DEVICE}="1" to hide it from a compositor.
+SUBSYSTEM=="input", ATTRS{id/bustype}=="%04x", ATTRS{id/vendor}=="%04x", ATTRS{id/product}=="%04x", ENV{ID_TOUCHPAD_TOOL}="1"
+`, busTypes[d.Bus], d.Vendor, d.Product)
+}
+
+// udevHwdb is a hwdb entry for the device, ready to be tuned.
+func udevHwdb(d DeviceConfig) string {
+	return fmt.Sprintf(`# Generated by touchpad-tool udev. After editing, run
+#   systemd-hwdb update && udevadm trigger /dev/input/event*
+
+# Pointer resolution used by libinput's acceleration, in dots per inch at
+# a report rate in Hz. 1000 is what libinput assumes without an entry.
+mouse:*:v%04xp%04x:name:*:
+ MOUSE_DPI=1000@125
+`, d.Vendor, d.Product)
+}
+
+// runUdev implements the `udev` subcommand. It returns the process exit
+// code.
+func runUdev(args []string) int {
+	cfg = loadConfig()
+	switch {
+	case len(args) == 0:
+		fmt.Printf("# %s\n%s\n# %s\n%s", udevRulesPath, udevRules(cfg.Device), udevHwdbPath, udevHwdb(cfg.Device))
+		return 0
+	case len(args) == 1 && args[0] == "install":
+		if err := installUdev(cfg.Device); err != nil {
+			fmt.Printf("[-] %v\n", err)
+			return 1
+		}
+		return 0
+	default:
+		fmt.Print(udevUsage)
+		return 2
+	}
+}
+
+// installUdev writes the rules and hwdb entry and has udev apply them.
+func installUdev(d DeviceConfig) error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("udev rules are only used on Linux")
+	}
+	files := map[string]string{
+		udevRulesPath: udevRules(d),
+		udevHwdbPath:  udevHwdb(d),
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("%v (run as root)", err)
+		}
+		fmt.Printf("[+] Wrote %s\n", path)
+	}
+	for _, args := range [][]string{
+		{"systemd-hwdb", "update"},
+		{"udevadm", "control", "--reload"},
+		{"udevadm", "trigger", "--subsystem-match=misc", "--subsystem-match=input"},
+	} {
+		if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			fmt.Printf("[!] %s failed: %v %s\n", args[0], err, out)
+		}
+	}
+	return nil
+}
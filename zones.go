@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mmngadi/touchpad-tool/internal/layout"
+)
+
+// zoneAt returns the index of the button zone the contact is in, or -1.
+func zoneAt(c *touchContact, pad digitizer) int {
+	x, y := pad.Normalize(c.x, c.y)
+	for i, z := range cfg.Zones {
+		if z.Contains(x, y) {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkZones drops button zones whose button the drivers do not know,
+// which they would otherwise press as the left button.
+func checkZones(zones []layout.Zone) []layout.Zone {
+	var ok []layout.Zone
+	for i, z := range zones {
+		if !knownButton(z.Button) {
+			fmt.Printf("[!] Ignoring button zone %d: unknown button %q\n", i+1, z.Button)
+			continue
+		}
+		ok = append(ok, z)
+	}
+	return ok
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/mmngadi/touchpad-tool/internal/layout"
+)
+
+func TestCheckZones(t *testing.T) {
+	zones := []layout.Zone{
+		{Button: "left", Top: 0.8, Right: 0.4, Bottom: 1},
+		{Button: "Right", Left: 0.6, Top: 0.8, Right: 1, Bottom: 1},
+		{Button: "middle", Left: 0.4, Top: 0.8, Right: 0.6, Bottom: 1},
+		{Button: "", Left: 0.4, Right: 0.6, Bottom: 0.2},
+		{Button: "back", Right: 0.1, Bottom: 0.2},
+	}
+	got := checkZones(zones)
+	want := []layout.Zone{zones[0], zones[2]}
+	if !slices.Equal(got, want) {
+		t.Errorf("checkZones kept %+v, want %+v", got, want)
+	}
+}
+
+// touchLines lands one contact at pad-space (x, y) with the given touch
+// size, holds it for a few frames and lifts it.
+func touchLines(x, y float64, major int) []string {
+	rawX, rawY := int(y*float64(testPad.maxX)), int((1-x)*float64(testPad.maxY))
+	lines := []string{
+		"EV_ABS ABS_MT_TRACKING_ID 00000007",
+		fmt.Sprintf("EV_ABS ABS_MT_POSITION_X %08x", rawX),
+		fmt.Sprintf("EV_ABS ABS_MT_POSITION_Y %08x", rawY),
+		fmt.Sprintf("EV_ABS ABS_MT_TOUCH_MAJOR %08x", major),
+		"EV_SYN SYN_REPORT 00000000",
+	}
+	for i := range 3 {
+		lines = append(lines,
+			fmt.Sprintf("EV_ABS ABS_MT_POSITION_X %08x", rawX+i%2),
+			"EV_SYN SYN_REPORT 00000000")
+	}
+	return append(lines,
+		"EV_ABS ABS_MT_TRACKING_ID ffffffff",
+		"EV_SYN SYN_REPORT 00000000")
+}
+
+func TestZonesAfterPalmRejection(t *testing.T) {
+	tests := []struct {
+		name  string
+		x, y  float64
+		major int
+		want  []string
+	}{
+		{"finger in zone", 0.2, 0.9, 0x1c, []string{"left down", "left up"}},
+		{"finger in right zone", 0.8, 0.85, 0x1c, []string{"right down", "right up"}},
+		{"palm in zone", 0.2, 0.9, 0xd0, nil},
+		{"graze in bottom edge of zone", 0.2, 0.98, 0x1c, nil},
+		{"graze in left edge of zone", 0.02, 0.9, 0x1c, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, f := newTestEngine(t)
+			cfg.Zones = []layout.Zone{
+				{Button: "left", Top: 0.8, Right: 0.5, Bottom: 1},
+				{Button: "right", Left: 0.5, Top: 0.8, Right: 1, Bottom: 1},
+			}
+			// Tapping would click too; only the zone's own button counts.
+			cfg.Bindings = nil
+			feed(e, eventLines(t, touchLines(tt.x, tt.y, tt.major)...))
+			if got := f.Events(); !slices.Equal(got, tt.want) {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}